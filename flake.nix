@@ -21,7 +21,7 @@
           pname = "oojsite";
           version = "0.1.0";
           src = ./.;
-          vendorHash = "sha256-gM37SLXNi4uY3uetmagNarbUvaFapQciajrguWVSd34=";
+          vendorHash = "sha256-SdRG7aloVN8yTFCGo92othasnMJMi6De9zfX/zuGNSw=";
           buildInputs = with pkgs; [
             makeWrapper
             tailwindcss
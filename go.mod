@@ -3,6 +3,14 @@ module oojsite
 go 1.24.5
 
 require (
-	github.com/kaleocheng/goldmark v1.1.10 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
+	github.com/BurntSushi/toml v1.5.0
+	github.com/alecthomas/chroma/v2 v2.24.1
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/kaleocheng/goldmark v1.1.10
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+require (
+	github.com/dlclark/regexp2 v1.12.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
 )
@@ -0,0 +1,265 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"oojsite/internal/config"
+	"oojsite/internal/content"
+	"oojsite/internal/output"
+	"oojsite/internal/scaffold"
+)
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(fs *flag.FlagSet, args []string) error
+}
+
+var commands = []command{
+	{"init", "init [flags] [dir]", "Create a new site from a starter theme", runInit},
+	{"build", "build [flags]", "Build the site into the output directory", runBuild},
+	{"serve", "serve [flags]", "Build the site, serve it on localhost and rebuild on changes", runServe},
+	{"new", "new post [flags] <title>", "Create a new post", runNew},
+	{"check", "check [flags]", "Build the site into a temporary directory and report any errors", runCheck},
+	{"clean", "clean [flags]", "Remove the generated output directory", runClean},
+}
+
+// Run dispatches to a subcommand. Invocations that don't start with one are
+// treated as the original flag-only interface, where --dev starts the server.
+func Run(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-h", "-help", "--help":
+			printUsage()
+			return nil
+		}
+
+		for _, cmd := range commands {
+			if cmd.name == args[0] {
+				fs := newFlagSet(cmd)
+				return ignoreHelp(cmd.run(fs, args[1:]))
+			}
+		}
+
+		if !strings.HasPrefix(args[0], "-") {
+			printUsage()
+			return fmt.Errorf("unknown command %q", args[0])
+		}
+	}
+
+	fs := flag.NewFlagSet("oojsite", flag.ContinueOnError)
+	fs.Usage = func() {
+		printUsage()
+		fmt.Fprintln(fs.Output(), "\nFlags (without a command):")
+		fs.PrintDefaults()
+	}
+	return ignoreHelp(runLegacy(fs, args))
+}
+
+func newFlagSet(cmd command) *flag.FlagSet {
+	fs := flag.NewFlagSet("oojsite "+cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oojsite %s\n\n%s.\n\nFlags:\n", cmd.usage, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func printUsage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: oojsite <command> [flags]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-28s %s\n", cmd.usage, cmd.summary)
+	}
+	fmt.Fprintln(out, "\nRun 'oojsite <command> -h' for the flags of a command.")
+	fmt.Fprintln(out, "Running oojsite with flags only builds the site, and serves it with --dev.")
+}
+
+// Usage has already been printed by the flag set.
+func ignoreHelp(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+func parseConfig(fs *flag.FlagSet, args []string) (*config.Config, error) {
+	log.Println("Parsing options...")
+	cfg, err := config.Parse(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkConfig(cfg); err != nil {
+		return nil, err
+	}
+	log.Println("Options parsed!")
+	return cfg, nil
+}
+
+// checkConfig validates the options config can't check itself without
+// depending on the packages that use them.
+func checkConfig(cfg *config.Config) error {
+	for _, name := range cfg.MarkdownExtensions {
+		if err := content.CheckExtension(name); err != nil {
+			return err
+		}
+	}
+	if cfg.Highlight.Enabled {
+		if _, err := content.LookupStyle(cfg.Highlight.Style); err != nil {
+			return err
+		}
+	}
+	if err := output.CheckSafe(cfg.OutDir, cfg.SourceDirs()); err != nil {
+		return fmt.Errorf("unsafe output directory: %w", err)
+	}
+	return nil
+}
+
+func runLegacy(fs *flag.FlagSet, args []string) error {
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Dev {
+		return serve(cfg, 8000)
+	}
+	return publish(cfg)
+}
+
+func runInit(fs *flag.FlagSet, args []string) error {
+	theme := fs.String("theme", "minimal", "Starter theme ("+strings.Join(scaffold.Themes(), ", ")+")")
+	tailwind := fs.Bool("tailwind", false, "Add a TailwindCSS stylesheet")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+
+	created, err := scaffold.Init(dir, *theme, *tailwind)
+	if err != nil {
+		return err
+	}
+	for _, path := range created {
+		log.Printf("Created %s", path)
+	}
+	log.Printf("Site created! Run 'oojsite serve' in %s to start writing.", dir)
+	return nil
+}
+
+func runBuild(fs *flag.FlagSet, args []string) error {
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	// --dev also turns on drafts and future posts, which must not be published
+	if cfg.Dev {
+		return errors.New("build does not take --dev; use 'oojsite serve' for development")
+	}
+
+	return publish(cfg)
+}
+
+func runServe(fs *flag.FlagSet, args []string) error {
+	port := fs.Int("port", 8000, "Port for the development server")
+
+	cfg, err := parseConfig(fs, append([]string{"--dev"}, args...))
+	if err != nil {
+		return err
+	}
+
+	return serve(cfg, *port)
+}
+
+func runNew(fs *flag.FlagSet, args []string) error {
+	if len(args) == 0 || args[0] != "post" {
+		fs.Usage()
+		return errors.New("expected 'new post <title>'")
+	}
+
+	section := fs.String("section", "", "Subdirectory of postDir to create the post in; also selects archetypes/<section>.md")
+
+	cfg, err := config.Parse(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	// Flag parsing stops at the title, so later flags would end up in it
+	for _, arg := range fs.Args() {
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && fs.Lookup(name) != nil {
+			return fmt.Errorf("flag %s must come before the title: oojsite new post [flags] <title>", arg)
+		}
+	}
+
+	title := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if title == "" {
+		fs.Usage()
+		return errors.New("missing post title")
+	}
+
+	path, err := newPost(cfg, title, *section)
+	if err != nil {
+		return err
+	}
+	log.Printf("Created %s", path)
+	return nil
+}
+
+func runCheck(fs *flag.FlagSet, args []string) error {
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "oojsite-check-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	sources, err := os.MkdirTemp("", "oojsite-check-sources-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(sources)
+	if err := cfg.StandInDirs(sources); err != nil {
+		return err
+	}
+
+	if err := build(cfg, dir); err != nil {
+		return err
+	}
+	log.Println("Site builds without errors!")
+	return nil
+}
+
+func runClean(fs *flag.FlagSet, args []string) error {
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Cleaning %s...", cfg.OutDir)
+	if err := output.Clean(cfg.OutDir, cfg.CleanGenerated); err != nil {
+		return err
+	}
+
+	// Leave the directory in place if it still holds files we didn't produce
+	if err := os.Remove(cfg.OutDir); err != nil && !os.IsNotExist(err) && !cfg.CleanGenerated {
+		return err
+	}
+	log.Println("Cleaned!")
+	return nil
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildRejectsDev(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "posts", "wip.md"), "---\ndraft: true\n---\nNot ready")
+	outDir := filepath.Join(root, "out")
+
+	if err := Run([]string{"build", "--allDir", root, "--outDir", outDir, "--dev"}); err == nil {
+		t.Fatal("expected build --dev to be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "posts", "wip", "index.html")); !os.IsNotExist(err) {
+		t.Fatalf("draft post was published: %v", err)
+	}
+}
+
+func TestNewRejectsFlagsAfterTitle(t *testing.T) {
+	root := t.TempDir()
+	postDir := filepath.Join(root, "posts")
+
+	if err := Run([]string{"new", "post", "--allDir", root, "My Title", "--section", "notes"}); err == nil {
+		t.Fatal("expected a flag after the title to be rejected")
+	}
+	if entries, _ := os.ReadDir(postDir); len(entries) != 0 {
+		t.Fatalf("expected no post to be created, got %v", entries)
+	}
+
+	if err := Run([]string{"new", "post", "--allDir", root, "--section", "notes", "My Title"}); err != nil {
+		t.Fatalf("new post: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(postDir, "notes", "my-title.md")); err != nil {
+		t.Fatalf("expected post in the notes section: %v", err)
+	}
+}
+
+func TestCheckLeavesTreeAlone(t *testing.T) {
+	root := t.TempDir()
+
+	if err := Run([]string{"check", "--allDir", root, "--outDir", filepath.Join(root, "out")}); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 0 {
+		t.Fatalf("expected check not to create anything, found %v", entries)
+	}
+}
+
+func TestParseConfigChecksOptions(t *testing.T) {
+	root := t.TempDir()
+	base := []string{"--allDir", root, "--outDir", filepath.Join(root, "out")}
+
+	for _, extra := range [][]string{
+		{"--markdownExtensions", "tables,emoji"},
+		{"--highlight", "--highlightStyle", "no-such-style"},
+		{"--outDir", root},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		if _, err := parseConfig(fs, append(append([]string{}, base...), extra...)); err == nil {
+			t.Fatalf("expected %v to be rejected", extra)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"oojsite/internal/config"
+	"oojsite/internal/templates"
+)
+
+const defaultArchetype = `---
+title: {{ printf "%q" .Title }}
+date: {{ .Date }}
+draft: true
+---
+
+`
+
+type archetypeData struct {
+	Title   string
+	Slug    string
+	Section string
+	Date    string
+	Now     time.Time
+}
+
+func newPost(cfg *config.Config, title, section string) (string, error) {
+	slug := templates.Slugify(title)
+	if slug == "" {
+		return "", fmt.Errorf("cannot derive a file name from title %q", title)
+	}
+
+	if section != "" {
+		clean := filepath.Clean(filepath.FromSlash(section))
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("section %q must be a directory inside the post dir", section)
+		}
+		section = filepath.ToSlash(clean)
+	}
+
+	path := filepath.Join(cfg.PostDir, filepath.FromSlash(section), slug+".md")
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+
+	name, archetype, err := findArchetype(cfg.ArchetypeDir, section)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New(name).Parse(archetype)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse archetype: %w", err)
+	}
+
+	now := time.Now()
+	data := archetypeData{
+		Title:   title,
+		Slug:    slug,
+		Section: section,
+		Date:    now.Format(cfg.DateFormat),
+		Now:     now,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute archetype: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// findArchetype prefers an archetype named after the section, then
+// archetypes/default.md, then the built-in default.
+func findArchetype(dir, section string) (string, string, error) {
+	var candidates []string
+	if section != "" {
+		candidates = append(candidates, filepath.Join(dir, filepath.FromSlash(section)+".md"))
+	}
+	candidates = append(candidates, filepath.Join(dir, "default.md"))
+
+	for _, path := range candidates {
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", "", err
+		}
+		return path, string(content), nil
+	}
+
+	return "default", defaultArchetype, nil
+}
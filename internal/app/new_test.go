@@ -0,0 +1,96 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"oojsite/internal/config"
+)
+
+func TestNewPostUsesSectionArchetype(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{
+		PostDir:      filepath.Join(root, "posts"),
+		ArchetypeDir: filepath.Join(root, "archetypes"),
+		DateFormat:   "2006-01-02",
+	}
+	if err := os.MkdirAll(cfg.ArchetypeDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.ArchetypeDir, "default.md"), []byte("default"), 0644); err != nil {
+		t.Fatalf("write default.md: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.ArchetypeDir, "news.md"), []byte("---\ntitle: {{ .Title }}\nslug: {{ .Slug }}\ndate: {{ .Date }}\n---\n"), 0644); err != nil {
+		t.Fatalf("write news.md: %v", err)
+	}
+
+	path, err := newPost(cfg, "Big Release", "news")
+	if err != nil {
+		t.Fatalf("newPost: %v", err)
+	}
+	if path != filepath.Join(cfg.PostDir, "news", "big-release.md") {
+		t.Fatalf("unexpected post path: %s", path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if !strings.Contains(string(content), "title: Big Release\nslug: big-release\ndate: 20") {
+		t.Fatalf("expected section archetype to be rendered, got: %s", content)
+	}
+
+	if _, err := newPost(cfg, "Big Release", "news"); err == nil {
+		t.Fatal("expected existing post not to be overwritten")
+	}
+}
+
+func TestNewPostFallsBackToBuiltinArchetype(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{
+		PostDir:      filepath.Join(root, "posts"),
+		ArchetypeDir: filepath.Join(root, "archetypes"),
+		DateFormat:   "January 2, 2006",
+	}
+
+	path, err := newPost(cfg, `Say "Hi": A Guide`, "")
+	if err != nil {
+		t.Fatalf("newPost: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if !strings.Contains(string(content), `title: "Say \"Hi\": A Guide"`) || !strings.Contains(string(content), "draft: true") {
+		t.Fatalf("unexpected built-in archetype output: %s", content)
+	}
+}
+
+func TestNewPostRejectsSectionsOutsidePostDir(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{
+		PostDir:      filepath.Join(root, "site", "posts"),
+		ArchetypeDir: filepath.Join(root, "archetypes"),
+		DateFormat:   "2006-01-02",
+	}
+
+	for _, section := range []string{"../../x", "notes/../../x", "..", "/tmp/x"} {
+		if path, err := newPost(cfg, "Escape", section); err == nil {
+			t.Fatalf("expected section %q to be rejected, wrote %s", section, path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "x")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing written outside the post dir, got %v", err)
+	}
+
+	path, err := newPost(cfg, "Inside", "notes/../guides")
+	if err != nil {
+		t.Fatalf("newPost: %v", err)
+	}
+	if path != filepath.Join(cfg.PostDir, "guides", "inside.md") {
+		t.Fatalf("unexpected post path: %s", path)
+	}
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"oojsite/internal/config"
+)
+
+type buildError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+type buildStatus struct {
+	mu  sync.Mutex
+	err *buildError
+}
+
+// set records the outcome of the latest build and returns the previous error.
+func (s *buildStatus) set(err *buildError) *buildError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev := s.err
+	s.err = err
+	return prev
+}
+
+func (s *buildStatus) get() *buildError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Matches both parse ("template: post.html:3: ...") and execution
+// ("template: post.html:12:5: executing ...") errors from html/template.
+var templateErrPattern = regexp.MustCompile(`template: ([^:\s]+):(\d+)(?::\d+)?: `)
+
+func describeError(cfg *config.Config, err error) *buildError {
+	desc := &buildError{Message: err.Error()}
+
+	if m := templateErrPattern.FindStringSubmatch(desc.Message); m != nil {
+		desc.File = findTemplateFile(cfg, m[1])
+		desc.Line, _ = strconv.Atoi(m[2])
+	}
+
+	return desc
+}
+
+// Templates are named relative to the directory they were loaded from, so
+// the failing file is looked up in each of them.
+func findTemplateFile(cfg *config.Config, name string) string {
+	for _, dir := range []string{cfg.TemplateDir, cfg.PageDir, cfg.ComponentDir} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return name
+}
+
+var overlayTmpl = template.Must(template.New("overlay").Parse(`<div id="oojsite-error-overlay" style="position:fixed;inset:0;z-index:2147483647;overflow:auto;background:rgba(20,20,20,0.92);color:#f5f5f5;font:14px/1.5 ui-monospace,SFMono-Regular,Menlo,monospace;padding:2rem;">
+  <div style="max-width:960px;margin:0 auto;">
+    <h2 style="margin:0 0 1rem;color:#ff6b6b;font-size:1.25rem;">Build failed</h2>
+    {{- with .File }}
+    <p style="margin:0 0 1rem;color:#ffd479;">{{ . }}{{ with $.Line }}:{{ . }}{{ end }}</p>
+    {{- end }}
+    <pre style="margin:0;white-space:pre-wrap;word-break:break-word;">{{ .Message }}</pre>
+    <p style="margin:1rem 0 0;color:#aaa;">Showing the last successful build. This page reloads once the next build succeeds.</p>
+  </div>
+</div>
+`))
+
+func renderOverlay(err *buildError) []byte {
+	var buf bytes.Buffer
+	if execErr := overlayTmpl.Execute(&buf, err); execErr != nil {
+		return []byte(template.HTMLEscapeString(err.Message))
+	}
+	return buf.Bytes()
+}
@@ -3,21 +3,23 @@ package app
 import (
 	"fmt"
 	"log"
-	"net/http"
+	"os"
+	"path/filepath"
+	"time"
 
 	"oojsite/internal/assets"
 	"oojsite/internal/config"
 	"oojsite/internal/content"
+	"oojsite/internal/model"
+	"oojsite/internal/output"
 	"oojsite/internal/templates"
 )
 
-func Run() error {
-	log.Println("Parsing options...")
-	cfg, err := config.Parse()
-	if err != nil {
-		return err
+func build(cfg *config.Config, outDir string) error {
+	buildTime := cfg.BuildTime
+	if buildTime.IsZero() {
+		buildTime = time.Now()
 	}
-	log.Println("Options parsed!")
 
 	log.Println("Loading templates...")
 	tmpls, err := templates.Load(cfg.TemplateDir, cfg.ComponentDir, cfg.PageDir)
@@ -26,45 +28,165 @@ func Run() error {
 	}
 	log.Println("Templates loaded!")
 
+	md, err := content.NewMarkdown(content.MarkdownOptions{
+		Extensions: cfg.MarkdownExtensions,
+		Highlight:  cfg.Highlight,
+		TOC:        cfg.TOC,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set up Markdown: %w", err)
+	}
+
 	log.Println("Loading posts...")
-	posts, err := content.LoadPosts(cfg.PostDir)
+	posts, err := content.LoadPosts(cfg.PostDir, content.LoadOptions{
+		Drafts:         cfg.Drafts,
+		Future:         cfg.Future,
+		Expired:        cfg.Expired,
+		BuildTime:      buildTime,
+		Permalink:      cfg.Permalink,
+		GitLastMod:     cfg.GitLastMod,
+		Markdown:       md,
+		WordsPerMinute: cfg.WordsPerMinute,
+		SnippetWords:   cfg.SnippetWords,
+		DateFormat:     cfg.DateFormat,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to load posts: %w", err)
 	}
 	log.Printf("Loaded %d posts!", len(posts))
 
+	log.Println("Loading data files...")
+	data, err := content.LoadData(cfg.DataDir)
+	if err != nil {
+		return fmt.Errorf("failed to load data files: %w", err)
+	}
+	log.Println("Data files loaded!")
+
+	global := model.GlobalData{
+		Posts:      posts,
+		Data:       data,
+		Taxonomies: content.BuildTaxonomies(posts, cfg.Taxonomies),
+	}
+
+	site := model.Site{
+		BaseURL:     cfg.BaseURL,
+		BuildTime:   buildTime,
+		Environment: cfg.Environment,
+		Params:      cfg.Params,
+	}
+
 	log.Println("Rendering posts...")
-	if err := content.RenderPosts(posts, cfg.OutDir, tmpls); err != nil {
+	if err := content.RenderPosts(global, site, outDir, tmpls, md); err != nil {
 		return fmt.Errorf("failed to render posts: %w", err)
 	}
 
+	// Terms hold copies of posts, so regroup now that their content is rendered
+	global.Taxonomies = content.BuildTaxonomies(posts, cfg.Taxonomies)
+
+	log.Println("Rendering taxonomies...")
+	if err := content.RenderTaxonomies(global, site, outDir, tmpls); err != nil {
+		return fmt.Errorf("failed to render taxonomies: %w", err)
+	}
+
 	log.Println("Rendering pages...")
-	if err := content.RenderPages(cfg.PageDir, cfg.OutDir, posts, tmpls); err != nil {
+	if err := content.RenderPages(cfg.PageDir, outDir, global, site, tmpls); err != nil {
 		return fmt.Errorf("failed to render pages: %w", err)
 	}
 
+	log.Println("Rendering aliases...")
+	aliases, err := content.RenderAliases(posts, site, outDir, cfg.Redirects)
+	if err != nil {
+		return fmt.Errorf("failed to render aliases: %w", err)
+	}
+
 	log.Println("Building TailwindCSS...")
-	if err := assets.BuildTailwind(cfg.OutDir, cfg.StaticDir); err != nil {
+	if err := assets.BuildTailwind(outDir, cfg.StaticDir); err != nil {
 		return fmt.Errorf("failed to build TailwindCSS: %w", err)
 	}
 	log.Println("TailwindCSS built!")
 
 	log.Println("Copying static files...")
-	if err := assets.CopyStaticContents(cfg.StaticDir, fmt.Sprintf("%s/static", cfg.OutDir)); err != nil {
+	if err := assets.CopyStaticContents(cfg.StaticDir, fmt.Sprintf("%s/static", outDir)); err != nil {
 		return fmt.Errorf("failed to copy static files: %w", err)
 	}
 	log.Println("Copied static files!")
 
-	log.Println("Building sitemap...")
-	if err := assets.BuildSitemap(cfg.BaseURL, cfg.OutDir); err != nil {
-		return fmt.Errorf("failed to build sitemap: %w", err)
+	if cfg.Highlight.Enabled && cfg.Highlight.Classes {
+		log.Println("Writing syntax highlighting stylesheet...")
+		css, err := content.HighlightCSS(cfg.Highlight)
+		if err != nil {
+			return fmt.Errorf("failed to write syntax highlighting stylesheet: %w", err)
+		}
+		if err := assets.BuildHighlightCSS(filepath.Join(outDir, "static"), css); err != nil {
+			return fmt.Errorf("failed to write syntax highlighting stylesheet: %w", err)
+		}
+	}
+
+	log.Println("Building feeds...")
+	if err := assets.BuildFeeds(global, site, outDir, assets.FeedOptions{
+		Formats:     cfg.Feeds,
+		Limit:       cfg.FeedLimit,
+		FullContent: cfg.FeedContent,
+		Sections:    cfg.SectionFeeds,
+		Terms:       cfg.TermFeeds,
+	}); err != nil {
+		return fmt.Errorf("failed to build feeds: %w", err)
+	}
+	log.Println("Built feeds!")
+
+	if cfg.Sitemap != "" {
+		log.Println("Building sitemap...")
+		entries, err := content.SitemapEntries(global, cfg.PageDir, cfg.GitLastMod)
+		if err != nil {
+			return fmt.Errorf("failed to build sitemap: %w", err)
+		}
+		// Redirect pages left behind by aliases aren't content
+		for _, rel := range aliases {
+			entries[rel] = model.SitemapEntry{Exclude: true}
+		}
+		if err := assets.BuildSitemap(cfg.BaseURL, outDir, cfg.Sitemap, entries); err != nil {
+			return fmt.Errorf("failed to build sitemap: %w", err)
+		}
+		log.Println("Built sitemap!")
+	}
+
+	log.Println("Rendering robots.txt...")
+	robots := model.RobotsData{
+		Site:       site,
+		Global:     global,
+		SitemapURL: assets.SitemapURL(cfg.BaseURL, cfg.Sitemap),
+	}
+	for _, post := range posts {
+		if post.Draft {
+			robots.Drafts = append(robots.Drafts, post)
+		}
 	}
-	log.Println("Built sitemap!")
+	if err := assets.BuildRobots(outDir, filepath.Join(cfg.TemplateDir, "robots.txt"), robots); err != nil {
+		return fmt.Errorf("failed to render robots.txt: %w", err)
+	}
+
+	return nil
+}
 
-	if !cfg.Dev {
-		return nil
+// publish builds into a staging directory next to the output directory and
+// only swaps it in once the build succeeded, so a broken build never replaces
+// the last good one.
+func publish(cfg *config.Config) error {
+	if err := cfg.CreateDirs(); err != nil {
+		return err
+	}
+	staging := filepath.Join(filepath.Dir(cfg.OutDir), "."+filepath.Base(cfg.OutDir)+".tmp")
+	if err := os.RemoveAll(staging); err != nil {
+		return fmt.Errorf("failed to clean staging directory: %w", err)
+	}
+	if err := build(cfg, staging); err != nil {
+		os.RemoveAll(staging)
+		return err
 	}
 
-	log.Println("Server started on localhost:8000!")
-	return http.ListenAndServe(":8000", http.FileServer(http.Dir(cfg.OutDir)))
+	if err := output.Replace(staging, cfg.OutDir, cfg.CleanGenerated); err != nil {
+		os.RemoveAll(staging)
+		return fmt.Errorf("failed to write output directory: %w", err)
+	}
+	return nil
 }
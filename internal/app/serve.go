@@ -0,0 +1,224 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"oojsite/internal/config"
+)
+
+const reloadPath = "/_oojsite/reload"
+
+const reloadScript = `<script>
+(function () {
+  var source = new EventSource("` + reloadPath + `");
+  source.addEventListener("reload", function () {
+    location.reload();
+  });
+  source.addEventListener("css", function () {
+    document.querySelectorAll('link[rel="stylesheet"]').forEach(function (link) {
+      var url = new URL(link.href);
+      if (url.origin !== location.origin) {
+        return;
+      }
+      url.searchParams.set("_oojsite", Date.now());
+      var next = link.cloneNode();
+      next.href = url.toString();
+      next.onload = function () {
+        link.remove();
+      };
+      link.after(next);
+    });
+  });
+})();
+</script>
+`
+
+func serve(cfg *config.Config, port int) error {
+	reloads := newReloader()
+	status := &buildStatus{}
+
+	if err := publish(cfg); err != nil {
+		log.Printf("Build failed: %v", err)
+		status.set(describeError(cfg, err))
+	}
+
+	err := watch(cfg.SourceDirs(), cfg.OutDir, func(changed []string) {
+		log.Printf("Detected %d changed file(s), rebuilding...", len(changed))
+		if err := publish(cfg); err != nil {
+			log.Printf("Rebuild failed: %v", err)
+			status.set(describeError(cfg, err))
+			reloads.broadcast("reload")
+			return
+		}
+		log.Println("Rebuilt site!")
+
+		// Clearing an overlay needs a full reload even for CSS-only changes.
+		hadError := status.set(nil) != nil
+		if !hadError && onlyStylesheets(changed) {
+			reloads.broadcast("css")
+		} else {
+			reloads.broadcast("reload")
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch for changes: %w", err)
+	}
+	log.Println("Watching for changes...")
+
+	mux := http.NewServeMux()
+	mux.Handle(reloadPath, reloads)
+	mux.Handle("/", injectDevClient(cfg.OutDir, status, http.FileServer(http.Dir(cfg.OutDir))))
+
+	log.Printf("Server started on localhost:%d!", port)
+	return http.ListenAndServe(fmt.Sprintf(":%d", port), mux)
+}
+
+func onlyStylesheets(changed []string) bool {
+	for _, path := range changed {
+		if filepath.Ext(path) != ".css" {
+			return false
+		}
+	}
+	return len(changed) > 0
+}
+
+type reloader struct {
+	mu      sync.Mutex
+	clients map[chan string]struct{}
+}
+
+func newReloader() *reloader {
+	return &reloader{clients: make(map[chan string]struct{})}
+}
+
+func (r *reloader) broadcast(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for client := range r.clients {
+		select {
+		case client <- event:
+		default:
+			// The client still has an event queued; it is about to reload anyway.
+		}
+	}
+}
+
+func (r *reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	events := make(chan string, 1)
+	r.mu.Lock()
+	r.clients[events] = struct{}{}
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.clients, events)
+		r.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case event := <-events:
+			fmt.Fprintf(w, "event: %s\ndata: {}\n\n", event)
+			flusher.Flush()
+		}
+	}
+}
+
+func injectDevClient(root string, status *buildStatus, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		buildErr := status.get()
+		snippet := []byte(reloadScript)
+		if buildErr != nil {
+			snippet = append(snippet, renderOverlay(buildErr)...)
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+
+		file, ok := resolveHTML(root, req.URL.Path)
+		if !ok {
+			// Without a previous good build there is nothing to overlay, so
+			// page requests get the error on its own.
+			if buildErr != nil && looksLikePage(req.URL.Path) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write(injectBeforeBodyEnd([]byte("<!DOCTYPE html>\n<html><body></body></html>"), snippet))
+				return
+			}
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		page, err := os.ReadFile(file)
+		if err != nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(injectBeforeBodyEnd(page, snippet))
+	})
+}
+
+func looksLikePage(urlPath string) bool {
+	return strings.HasSuffix(urlPath, "/") || path.Ext(urlPath) == ".html"
+}
+
+// resolveHTML maps a request path onto the HTML file http.FileServer would
+// serve for it. Directory requests without a trailing slash are left to the
+// file server so it can redirect them.
+func resolveHTML(root, urlPath string) (string, bool) {
+	file := filepath.Join(root, filepath.FromSlash(path.Clean("/"+urlPath)))
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", false
+	}
+	if info.IsDir() {
+		if !strings.HasSuffix(urlPath, "/") {
+			return "", false
+		}
+		file = filepath.Join(file, "index.html")
+		if _, err := os.Stat(file); err != nil {
+			return "", false
+		}
+	}
+	return file, filepath.Ext(file) == ".html"
+}
+
+func injectBeforeBodyEnd(page, snippet []byte) []byte {
+	idx := bytes.LastIndex(bytes.ToLower(page), []byte("</body>"))
+	if idx < 0 {
+		return append(page, snippet...)
+	}
+
+	out := make([]byte, 0, len(page)+len(snippet))
+	out = append(out, page[:idx]...)
+	out = append(out, snippet...)
+	return append(out, page[idx:]...)
+}
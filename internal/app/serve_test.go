@@ -0,0 +1,60 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"oojsite/internal/config"
+)
+
+func TestInjectBeforeBodyEnd(t *testing.T) {
+	got := string(injectBeforeBodyEnd([]byte("<html><BODY><p>hi</p></BODY></html>"), []byte("<script></script>")))
+	if got != "<html><BODY><p>hi</p><script></script></BODY></html>" {
+		t.Fatalf("unexpected injection result: %s", got)
+	}
+
+	got = string(injectBeforeBodyEnd([]byte("<p>fragment</p>"), []byte("<script></script>")))
+	if !strings.HasSuffix(got, "<script></script>") {
+		t.Fatalf("expected snippet appended to fragment, got: %s", got)
+	}
+}
+
+func TestOnlyStylesheets(t *testing.T) {
+	if !onlyStylesheets([]string{"static/a.css", "static/b.css"}) {
+		t.Fatal("expected css-only change set to be detected")
+	}
+	if onlyStylesheets([]string{"static/a.css", "templates/post.html"}) {
+		t.Fatal("expected mixed change set to require a full reload")
+	}
+	if onlyStylesheets(nil) {
+		t.Fatal("expected empty change set to require a full reload")
+	}
+}
+
+func TestDescribeErrorLocatesTemplate(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{
+		TemplateDir:  filepath.Join(root, "templates"),
+		PageDir:      filepath.Join(root, "site"),
+		ComponentDir: filepath.Join(root, "components"),
+	}
+	if err := os.MkdirAll(cfg.PageDir, 0755); err != nil {
+		t.Fatalf("mkdir %s: %v", cfg.PageDir, err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.PageDir, "index.html"), []byte("{{ .Nope }}"), 0644); err != nil {
+		t.Fatalf("write index.html: %v", err)
+	}
+
+	desc := describeError(cfg, errors.New(`failed to render pages: template: index.html:7:3: executing "index.html" at <.Nope>: can't evaluate field Nope`))
+	if desc.File != filepath.Join(cfg.PageDir, "index.html") || desc.Line != 7 {
+		t.Fatalf("unexpected error location: %s:%d", desc.File, desc.Line)
+	}
+
+	overlay := string(renderOverlay(desc))
+	if !strings.Contains(overlay, "index.html:7") || !strings.Contains(overlay, "&lt;.Nope&gt;") {
+		t.Fatalf("expected escaped error details in overlay, got: %s", overlay)
+	}
+}
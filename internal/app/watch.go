@@ -0,0 +1,111 @@
+package app
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"oojsite/internal/output"
+)
+
+// Editors tend to write a file in several steps (truncate, write, rename),
+// so changes are collected until things have been quiet for this long.
+const debounceDelay = 150 * time.Millisecond
+
+func watch(dirs []string, outDir string, onChange func(changed []string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		// Optional source dirs may not exist
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		if err := addRecursive(watcher, dir, outDir); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		pending := make(map[string]bool)
+		var fire <-chan time.Time
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if ignoreEvent(event, outDir) {
+					continue
+				}
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						if err := addRecursive(watcher, event.Name, outDir); err != nil {
+							log.Printf("Failed to watch %s: %v", event.Name, err)
+						}
+					}
+				}
+				pending[event.Name] = true
+				fire = time.After(debounceDelay)
+
+			case <-fire:
+				changed := make([]string, 0, len(pending))
+				for path := range pending {
+					changed = append(changed, path)
+				}
+				sort.Strings(changed)
+				pending = make(map[string]bool)
+				fire = nil
+				onChange(changed)
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Watcher error: %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func addRecursive(watcher *fsnotify.Watcher, root, outDir string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if output.Contains(outDir, path) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+func ignoreEvent(event fsnotify.Event, outDir string) bool {
+	if event.Op == fsnotify.Chmod {
+		return true
+	}
+	if output.Contains(outDir, event.Name) {
+		return true
+	}
+
+	// Swap, backup and lock files from common editors.
+	name := filepath.Base(event.Name)
+	return strings.HasPrefix(name, ".#") ||
+		strings.HasSuffix(name, "~") ||
+		strings.HasSuffix(name, ".swp") ||
+		strings.HasSuffix(name, ".swx")
+}
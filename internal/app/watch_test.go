@@ -0,0 +1,76 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestIgnoreEvent(t *testing.T) {
+	root := t.TempDir()
+	outDir := filepath.Join(root, "out")
+
+	cases := []struct {
+		name   string
+		event  fsnotify.Event
+		ignore bool
+	}{
+		{"post write", fsnotify.Event{Name: filepath.Join(root, "posts", "a.md"), Op: fsnotify.Write}, false},
+		{"new file", fsnotify.Event{Name: filepath.Join(root, "posts", "b.md"), Op: fsnotify.Create}, false},
+		{"chmod only", fsnotify.Event{Name: filepath.Join(root, "posts", "a.md"), Op: fsnotify.Chmod}, true},
+		{"write with chmod", fsnotify.Event{Name: filepath.Join(root, "posts", "a.md"), Op: fsnotify.Write | fsnotify.Chmod}, false},
+		{"output file", fsnotify.Event{Name: filepath.Join(outDir, "index.html"), Op: fsnotify.Write}, true},
+		{"vim swap", fsnotify.Event{Name: filepath.Join(root, "posts", ".a.md.swp"), Op: fsnotify.Create}, true},
+		{"vim swap overflow", fsnotify.Event{Name: filepath.Join(root, "posts", ".a.md.swx"), Op: fsnotify.Create}, true},
+		{"backup", fsnotify.Event{Name: filepath.Join(root, "posts", "a.md~"), Op: fsnotify.Create}, true},
+		{"emacs lock", fsnotify.Event{Name: filepath.Join(root, "posts", ".#a.md"), Op: fsnotify.Create}, true},
+	}
+	for _, c := range cases {
+		if got := ignoreEvent(c.event, outDir); got != c.ignore {
+			t.Fatalf("%s: ignoreEvent(%v) = %v, want %v", c.name, c.event, got, c.ignore)
+		}
+	}
+}
+
+func TestWatchMergesBurstOfSaves(t *testing.T) {
+	root := t.TempDir()
+	postsDir := filepath.Join(root, "posts")
+	if err := os.MkdirAll(postsDir, 0755); err != nil {
+		t.Fatalf("mkdir %s: %v", postsDir, err)
+	}
+
+	changes := make(chan []string, 10)
+	if err := watch([]string{postsDir}, filepath.Join(root, "out"), func(changed []string) {
+		changes <- changed
+	}); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+
+	post := filepath.Join(postsDir, "a.md")
+	for _, body := range []string{"one", "two", "three"} {
+		if err := os.WriteFile(post, []byte(body), 0644); err != nil {
+			t.Fatalf("write %s: %v", post, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(postsDir, ".a.md.swp"), []byte("swap"), 0644); err != nil {
+		t.Fatalf("write swap file: %v", err)
+	}
+
+	select {
+	case changed := <-changes:
+		if len(changed) != 1 || changed[0] != post {
+			t.Fatalf("expected a single change for %s, got %v", post, changed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no rebuild after saving a post")
+	}
+
+	select {
+	case changed := <-changes:
+		t.Fatalf("expected the saves to be merged into one rebuild, got another for %v", changed)
+	case <-time.After(3 * debounceDelay):
+	}
+}
@@ -0,0 +1,363 @@
+package assets
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"oojsite/internal/model"
+	"oojsite/internal/templates"
+)
+
+type FeedOptions struct {
+	Formats     []string
+	Limit       int
+	FullContent bool
+	Sections    bool
+	Terms       bool
+}
+
+type feed struct {
+	Title       string
+	Description string
+	Author      string
+	SiteURL     string
+	Dir         string // where the feed is written
+	Link        string // the page the feed points readers to
+	Updated     time.Time
+	Posts       []model.Post
+}
+
+// BuildFeeds writes a feed in every configured format for the whole site and,
+// when enabled, for each post section and taxonomy term.
+func BuildFeeds(global model.GlobalData, site model.Site, outDir string, opts FeedOptions) error {
+	if len(opts.Formats) == 0 {
+		return nil
+	}
+
+	if !model.IsAbsURL(site.BaseURL) {
+		log.Printf("warning: feeds need an absolute --baseUrl such as https://example.com, got %q; their links will be relative", site.BaseURL)
+	}
+
+	title, _ := site.Params["title"].(string)
+	if title == "" && model.IsAbsURL(site.BaseURL) {
+		title = site.BaseURL
+	}
+	if title == "" {
+		title = "Feed"
+	}
+	description, _ := site.Params["description"].(string)
+	author, _ := site.Params["author"].(string)
+
+	feeds := []feed{{Title: title, Description: description, Dir: "/", Link: "/", Posts: global.Posts}}
+
+	if opts.Sections {
+		sections := make(map[string][]model.Post)
+		for _, post := range global.Posts {
+			if post.Section != "" {
+				sections[post.Section] = append(sections[post.Section], post)
+			}
+		}
+		// Sections have no page of their own, so their feeds link home
+		for section, posts := range sections {
+			feeds = append(feeds, feed{
+				Title: title + " - " + section,
+				Dir:   "/" + templates.Slugify(section) + "/",
+				Link:  "/",
+				Posts: posts,
+			})
+		}
+	}
+
+	if opts.Terms {
+		for _, taxonomy := range global.Taxonomies {
+			for _, term := range taxonomy.Terms {
+				feeds = append(feeds, feed{
+					Title: title + " - " + term.Name,
+					Dir:   term.URL,
+					Link:  term.URL,
+					Posts: term.Posts,
+				})
+			}
+		}
+	}
+
+	for _, f := range feeds {
+		f.Author = author
+		f.SiteURL = model.AbsURL(site.BaseURL, f.Link)
+		f.Posts = latestPosts(f.Posts, opts.Limit)
+		f.Updated = site.BuildTime
+		if len(f.Posts) > 0 && !f.Posts[0].Date.IsZero() {
+			f.Updated = f.Posts[0].Date
+		}
+
+		for _, format := range opts.Formats {
+			if err := writeFeed(f, site, outDir, format, opts.FullContent); err != nil {
+				return fmt.Errorf("failed to write %s feed for %s: %w", format, f.Dir, err)
+			}
+		}
+	}
+	return nil
+}
+
+// latestPosts returns up to limit posts, newest first. Undated posts go last.
+func latestPosts(posts []model.Post, limit int) []model.Post {
+	sorted := append([]model.Post(nil), posts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.After(sorted[j].Date)
+	})
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+func writeFeed(f feed, site model.Site, outDir, format string, fullContent bool) error {
+	name, ok := model.FeedFiles[format]
+	if !ok {
+		return fmt.Errorf("unknown feed format %q", format)
+	}
+	feedURL := model.AbsURL(site.BaseURL, path.Join(f.Dir, name))
+
+	var data []byte
+	var err error
+	switch format {
+	case "rss":
+		data, err = rssFeed(f, site, feedURL, fullContent)
+	case "atom":
+		data, err = atomFeed(f, site, feedURL, fullContent)
+	case "json":
+		data, err = jsonFeed(f, site, feedURL, fullContent)
+	}
+	if err != nil {
+		return err
+	}
+
+	outPath := filepath.Join(outDir, filepath.FromSlash(strings.TrimPrefix(f.Dir, "/")), name)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, data, 0644)
+}
+
+func postTitle(post model.Post) string {
+	if title, ok := post.Frontmatter["title"].(string); ok && title != "" {
+		return title
+	}
+	return strings.TrimSuffix(filepath.Base(post.SourcePath), filepath.Ext(post.SourcePath))
+}
+
+func postBody(post model.Post, fullContent bool) string {
+	if fullContent {
+		return string(post.Content)
+	}
+	return post.Snippet
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+	Description string  `xml:"description"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	AtomLink      rssLink   `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rss struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	XMLNSAtom string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+func rssFeed(f feed, site model.Site, feedURL string, fullContent bool) ([]byte, error) {
+	channel := rssChannel{
+		Title:         f.Title,
+		Link:          f.SiteURL,
+		Description:   f.Description,
+		AtomLink:      rssLink{Href: feedURL, Rel: "self", Type: "application/rss+xml"},
+		LastBuildDate: site.BuildTime.Format(time.RFC1123Z),
+	}
+	if channel.Description == "" {
+		channel.Description = f.Title
+	}
+
+	for _, post := range f.Posts {
+		url := model.AbsURL(site.BaseURL, post.Filepath)
+		item := rssItem{
+			Title:       postTitle(post),
+			Link:        url,
+			GUID:        rssGUID{Value: url, IsPermaLink: true},
+			Description: postBody(post, fullContent),
+		}
+		if !post.Date.IsZero() {
+			item.PubDate = post.Date.Format(time.RFC1123Z)
+		}
+		channel.Items = append(channel.Items, item)
+	}
+
+	return encodeXML(rss{Version: "2.0", XMLNSAtom: "http://www.w3.org/2005/Atom", Channel: channel})
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title     string    `xml:"title"`
+	Link      atomLink  `xml:"link"`
+	ID        string    `xml:"id"`
+	Published string    `xml:"published,omitempty"`
+	Updated   string    `xml:"updated"`
+	Summary   *atomText `xml:"summary,omitempty"`
+	Content   *atomText `xml:"content,omitempty"`
+}
+
+type atom struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomPerson  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+func atomFeed(f feed, site model.Site, feedURL string, fullContent bool) ([]byte, error) {
+	doc := atom{
+		XMLNS:   "http://www.w3.org/2005/Atom",
+		Title:   f.Title,
+		ID:      feedURL,
+		Updated: f.Updated.Format(time.RFC3339),
+		Links:   []atomLink{{Href: f.SiteURL}, {Href: feedURL, Rel: "self"}},
+	}
+	// Atom requires an author; fall back to the site itself
+	doc.Author.Name = f.Author
+	if f.Author == "" {
+		doc.Author.Name = f.Title
+	}
+
+	for _, post := range f.Posts {
+		url := model.AbsURL(site.BaseURL, post.Filepath)
+		entry := atomEntry{
+			Title:   postTitle(post),
+			Link:    atomLink{Href: url},
+			ID:      url,
+			Updated: f.Updated.Format(time.RFC3339),
+		}
+		if !post.Date.IsZero() {
+			entry.Published = post.Date.Format(time.RFC3339)
+			entry.Updated = entry.Published
+		}
+		if fullContent {
+			entry.Content = &atomText{Type: "html", Value: string(post.Content)}
+		} else {
+			entry.Summary = &atomText{Value: post.Snippet}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return encodeXML(doc)
+}
+
+func encodeXML(v any) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url"`
+	Title         string `json:"title"`
+	ContentHTML   string `json:"content_html,omitempty"`
+	ContentText   string `json:"content_text,omitempty"`
+	DatePublished string `json:"date_published,omitempty"`
+}
+
+type jsonFeedDoc struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	FeedURL     string           `json:"feed_url"`
+	Description string           `json:"description,omitempty"`
+	Authors     []jsonFeedAuthor `json:"authors,omitempty"`
+	Items       []jsonFeedItem   `json:"items"`
+}
+
+func jsonFeed(f feed, site model.Site, feedURL string, fullContent bool) ([]byte, error) {
+	doc := jsonFeedDoc{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.SiteURL,
+		FeedURL:     feedURL,
+		Description: f.Description,
+		Items:       []jsonFeedItem{},
+	}
+	if f.Author != "" {
+		doc.Authors = []jsonFeedAuthor{{Name: f.Author}}
+	}
+
+	for _, post := range f.Posts {
+		url := model.AbsURL(site.BaseURL, post.Filepath)
+		item := jsonFeedItem{ID: url, URL: url, Title: postTitle(post)}
+		if fullContent {
+			item.ContentHTML = string(post.Content)
+		} else {
+			item.ContentText = post.Snippet
+		}
+		if !post.Date.IsZero() {
+			item.DatePublished = post.Date.Format(time.RFC3339)
+		}
+		doc.Items = append(doc.Items, item)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
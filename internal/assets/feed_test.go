@@ -0,0 +1,100 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"oojsite/internal/model"
+)
+
+func feedPost(path, title string, date time.Time) model.Post {
+	return model.Post{
+		SourcePath:  "posts/" + path + ".md",
+		Filepath:    "/posts/" + path + "/",
+		Section:     filepath.Dir(path),
+		Frontmatter: map[string]interface{}{"title": title},
+		Date:        date,
+		Snippet:     title + " snippet",
+		Content:     "<p>full</p>",
+	}
+}
+
+func TestBuildFeedsWritesEachFormat(t *testing.T) {
+	outDir := t.TempDir()
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	global := model.GlobalData{Posts: []model.Post{
+		feedPost("news/old", "Old", day),
+		feedPost("news/new", "New", day.AddDate(0, 0, 2)),
+		feedPost("guides/mid", "Mid", day.AddDate(0, 0, 1)),
+	}}
+	site := model.Site{BaseURL: "https://example.com", BuildTime: day, Params: map[string]interface{}{"title": "Example"}}
+
+	err := BuildFeeds(global, site, outDir, FeedOptions{Formats: []string{"rss", "atom", "json"}, Limit: 2, Sections: true})
+	if err != nil {
+		t.Fatalf("BuildFeeds: %v", err)
+	}
+
+	rss := readOut(t, filepath.Join(outDir, "feed.xml"))
+	if !strings.Contains(rss, "<link>https://example.com/posts/news/new/</link>") || strings.Contains(rss, "Old") {
+		t.Fatalf("expected the two newest posts in the RSS feed:\n%s", rss)
+	}
+	if !strings.Contains(rss, `<atom:link href="https://example.com/feed.xml" rel="self"`) {
+		t.Fatalf("RSS feed missing self link:\n%s", rss)
+	}
+
+	atom := readOut(t, filepath.Join(outDir, "atom.xml"))
+	if !strings.Contains(atom, "<updated>2024-03-03T00:00:00Z</updated>") {
+		t.Fatalf("Atom feed should be updated as of the newest post:\n%s", atom)
+	}
+
+	var doc jsonFeedDoc
+	if err := json.Unmarshal([]byte(readOut(t, filepath.Join(outDir, "feed.json"))), &doc); err != nil {
+		t.Fatalf("decode JSON feed: %v", err)
+	}
+	if len(doc.Items) != 2 || doc.Items[0].Title != "New" || doc.Items[1].ContentText != "Mid snippet" {
+		t.Fatalf("unexpected JSON feed items: %+v", doc.Items)
+	}
+
+	section := readOut(t, filepath.Join(outDir, "news", "feed.xml"))
+	if !strings.Contains(section, "Old") || strings.Contains(section, "Mid") {
+		t.Fatalf("section feed should only hold its own posts:\n%s", section)
+	}
+	if !strings.Contains(section, "<link>https://example.com/</link>") {
+		t.Fatalf("section feed should link to the site, not /news/:\n%s", section)
+	}
+}
+
+func TestBuildFeedsWarnsWithoutAbsoluteBaseURL(t *testing.T) {
+	outDir := t.TempDir()
+	global := model.GlobalData{Posts: []model.Post{feedPost("a", "A", time.Now())}}
+	site := model.Site{BaseURL: "baseUrl", BuildTime: time.Now()}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	if err := BuildFeeds(global, site, outDir, FeedOptions{Formats: []string{"rss"}}); err != nil {
+		t.Fatalf("BuildFeeds: %v", err)
+	}
+	if !strings.Contains(logs.String(), "feeds need an absolute --baseUrl") {
+		t.Fatalf("expected a warning about the base URL, got %q", logs.String())
+	}
+	if rss := readOut(t, filepath.Join(outDir, "feed.xml")); strings.Contains(rss, "<title>baseUrl</title>") {
+		t.Fatalf("feed title should not fall back to the placeholder base URL:\n%s", rss)
+	}
+}
+
+func readOut(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
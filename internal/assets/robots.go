@@ -0,0 +1,56 @@
+package assets
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"oojsite/internal/model"
+	"oojsite/internal/templates"
+)
+
+const defaultRobots = `User-agent: *
+Allow: /
+{{ if .SitemapURL }}
+Sitemap: {{ .SitemapURL }}
+{{ end }}`
+
+// SitemapURL returns the absolute URL of the sitemap, or "" when it is
+// disabled or the base URL isn't absolute, as robots.txt can't use a
+// relative one.
+func SitemapURL(baseURL, sitemapPath string) string {
+	if sitemapPath == "" || !model.IsAbsURL(baseURL) {
+		return ""
+	}
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimPrefix(sitemapPath, "/")
+}
+
+// BuildRobots renders robots.txt at the root of outDir from tmplPath, falling
+// back to one that allows everything and points crawlers at the sitemap.
+func BuildRobots(outDir, tmplPath string, data model.RobotsData) error {
+	source := defaultRobots
+	name := "robots.txt"
+	if content, err := os.ReadFile(tmplPath); err == nil {
+		source = string(content)
+		name = filepath.Base(tmplPath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	tmpl, err := template.New(name).Funcs(template.FuncMap(templates.Funcs())).Parse(source)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "robots.txt"), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write robots.txt: %w", err)
+	}
+	return nil
+}
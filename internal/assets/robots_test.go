@@ -0,0 +1,70 @@
+package assets
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"oojsite/internal/model"
+)
+
+func TestBuildRobotsDefault(t *testing.T) {
+	outDir := t.TempDir()
+	data := model.RobotsData{SitemapURL: SitemapURL("https://example.com/", "sitemap.xml")}
+	if err := BuildRobots(outDir, filepath.Join(outDir, "missing.txt"), data); err != nil {
+		t.Fatalf("BuildRobots: %v", err)
+	}
+
+	want := "User-agent: *\nAllow: /\n\nSitemap: https://example.com/sitemap.xml\n"
+	if got := readOut(t, filepath.Join(outDir, "robots.txt")); got != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestBuildRobotsOmitsRelativeSitemap(t *testing.T) {
+	outDir := t.TempDir()
+	data := model.RobotsData{SitemapURL: SitemapURL("baseUrl", "sitemap.xml")}
+	if err := BuildRobots(outDir, filepath.Join(outDir, "missing.txt"), data); err != nil {
+		t.Fatalf("BuildRobots: %v", err)
+	}
+
+	if got := readOut(t, filepath.Join(outDir, "robots.txt")); strings.Contains(got, "Sitemap:") {
+		t.Fatalf("expected no Sitemap line without an absolute base URL, got:\n%s", got)
+	}
+}
+
+func TestBuildRobotsFromTemplate(t *testing.T) {
+	outDir := t.TempDir()
+	tmplPath := filepath.Join(t.TempDir(), "robots.txt")
+	writeOut(t, tmplPath, `User-agent: *
+{{- if ne .Site.Environment "production" }}
+Disallow: /
+{{- else }}
+{{- range .Drafts }}
+Disallow: {{ .Filepath }}
+{{- end }}
+Sitemap: {{ .SitemapURL }}
+{{- end }}
+`)
+
+	data := model.RobotsData{
+		Site:       model.Site{Environment: "production"},
+		SitemapURL: "https://example.com/sitemap.xml",
+		Drafts:     []model.Post{{Filepath: "/posts/wip/"}},
+	}
+	if err := BuildRobots(outDir, tmplPath, data); err != nil {
+		t.Fatalf("BuildRobots: %v", err)
+	}
+	want := "User-agent: *\nDisallow: /posts/wip/\nSitemap: https://example.com/sitemap.xml\n"
+	if got := readOut(t, filepath.Join(outDir, "robots.txt")); got != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	data.Site.Environment = "staging"
+	if err := BuildRobots(outDir, tmplPath, data); err != nil {
+		t.Fatalf("BuildRobots: %v", err)
+	}
+	if got := readOut(t, filepath.Join(outDir, "robots.txt")); got != "User-agent: *\nDisallow: /\n" {
+		t.Fatalf("expected staging to disallow everything, got:\n%s", got)
+	}
+}
@@ -3,17 +3,27 @@ package assets
 import (
 	"encoding/xml"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"oojsite/internal/model"
 )
 
+const sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// sitemapLimit is the most URLs the sitemap protocol allows in one file.
+var sitemapLimit = 50000
+
 type URL struct {
-	Loc        string  `xml:"loc"`
-	LastMod    string  `xml:"lastmod"`
-	ChangeFreq string  `xml:"changefreq"`
-	Priority   float64 `xml:"priority"`
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+
+	modified time.Time
 }
 
 type Sitemap struct {
@@ -22,12 +32,62 @@ type Sitemap struct {
 	URLs    []URL    `xml:"url"`
 }
 
-func BuildSitemap(baseURL, outDir string) error {
-	sitemap := Sitemap{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
-	if err := addFilesToSitemap(baseURL, outDir, &sitemap); err != nil {
+type SitemapRef struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type SitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []SitemapRef `xml:"sitemap"`
+}
+
+// BuildSitemap lists every HTML file in outDir, using entries (keyed by the
+// slash-separated path relative to outDir) for lastmod and other details. The
+// sitemap is written to sitemapPath inside outDir; beyond sitemapLimit URLs it
+// is split into numbered files next to it and sitemapPath becomes their index.
+func BuildSitemap(baseURL, outDir, sitemapPath string, entries map[string]model.SitemapEntry) error {
+	if !model.IsAbsURL(baseURL) {
+		log.Printf("warning: the sitemap needs an absolute --baseUrl such as https://example.com, got %q; robots.txt will leave it out", baseURL)
+	}
+
+	sitemap := Sitemap{XMLNS: sitemapXMLNS}
+	if err := addFilesToSitemap(baseURL, outDir, entries, &sitemap); err != nil {
 		return err
 	}
-	return generateSitemapFile(outDir, &sitemap)
+
+	target := filepath.Join(outDir, filepath.FromSlash(strings.TrimPrefix(sitemapPath, "/")))
+	if len(sitemap.URLs) <= sitemapLimit {
+		return writeXML(target, &sitemap)
+	}
+
+	index := SitemapIndex{XMLNS: sitemapXMLNS}
+	ext := filepath.Ext(target)
+	for n, start := 1, 0; start < len(sitemap.URLs); n, start = n+1, start+sitemapLimit {
+		part := Sitemap{XMLNS: sitemapXMLNS, URLs: sitemap.URLs[start:min(start+sitemapLimit, len(sitemap.URLs))]}
+		partPath := fmt.Sprintf("%s-%d%s", strings.TrimSuffix(target, ext), n, ext)
+		if err := writeXML(partPath, &part); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(outDir, partPath)
+		if err != nil {
+			return err
+		}
+		ref := SitemapRef{Loc: strings.TrimRight(baseURL, "/") + "/" + filepath.ToSlash(rel)}
+		var newest time.Time
+		for _, url := range part.URLs {
+			if url.modified.After(newest) {
+				newest = url.modified
+			}
+		}
+		if !newest.IsZero() {
+			ref.LastMod = newest.Format(time.RFC3339)
+		}
+		index.Sitemaps = append(index.Sitemaps, ref)
+	}
+	return writeXML(target, &index)
 }
 
 func generateURL(baseURL, outDir, filePath string) string {
@@ -48,7 +108,7 @@ func generateURL(baseURL, outDir, filePath string) string {
 	return strings.TrimRight(baseURL, "/") + rel
 }
 
-func addFilesToSitemap(baseURL, outDir string, sitemap *Sitemap) error {
+func addFilesToSitemap(baseURL, outDir string, entries map[string]model.SitemapEntry, sitemap *Sitemap) error {
 	return filepath.Walk(outDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -56,25 +116,36 @@ func addFilesToSitemap(baseURL, outDir string, sitemap *Sitemap) error {
 		if info.IsDir() || filepath.Ext(path) != ".html" {
 			return nil
 		}
+		rel, err := filepath.Rel(outDir, path)
+		if err != nil {
+			return err
+		}
+		entry := entries[filepath.ToSlash(rel)]
+		if entry.Exclude {
+			return nil
+		}
 
-		sitemap.URLs = append(sitemap.URLs, URL{
+		url := URL{
 			Loc:        generateURL(baseURL, outDir, path),
-			LastMod:    time.Now().Format("2006-01-02"),
-			ChangeFreq: "monthly",
-			Priority:   0.5,
-		})
+			ChangeFreq: entry.ChangeFreq,
+			Priority:   entry.Priority,
+		}
+		if !entry.LastMod.IsZero() {
+			url.LastMod = entry.LastMod.Format(time.RFC3339)
+			url.modified = entry.LastMod
+		}
+		sitemap.URLs = append(sitemap.URLs, url)
 		return nil
 	})
 }
 
-func generateSitemapFile(outDir string, sitemap *Sitemap) error {
-	file, err := os.Create(fmt.Sprintf("%s/static/sitemap.xml", outDir))
+func writeXML(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := encodeXML(v)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	enc := xml.NewEncoder(file)
-	enc.Indent("", "  ")
-	return enc.Encode(sitemap)
+	return os.WriteFile(path, data, 0644)
 }
@@ -0,0 +1,70 @@
+package assets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"oojsite/internal/model"
+)
+
+func writeOut(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestBuildSitemapAtConfiguredPath(t *testing.T) {
+	outDir := t.TempDir()
+	writeOut(t, filepath.Join(outDir, "index.html"), "")
+	writeOut(t, filepath.Join(outDir, "posts", "a", "index.html"), "")
+	writeOut(t, filepath.Join(outDir, "old", "index.html"), "")
+
+	entries := map[string]model.SitemapEntry{
+		"posts/a/index.html": {LastMod: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Priority: "0.8"},
+		"old/index.html":     {Exclude: true},
+	}
+	if err := BuildSitemap("https://example.com/", outDir, "sitemap.xml", entries); err != nil {
+		t.Fatalf("BuildSitemap: %v", err)
+	}
+
+	sitemap := readOut(t, filepath.Join(outDir, "sitemap.xml"))
+	if !strings.Contains(sitemap, "<loc>https://example.com/posts/a/</loc>\n    <lastmod>2024-01-02T00:00:00Z</lastmod>\n    <priority>0.8</priority>") {
+		t.Fatalf("sitemap missing post details:\n%s", sitemap)
+	}
+	if strings.Contains(sitemap, "/old/") || strings.Contains(sitemap, "changefreq") {
+		t.Fatalf("sitemap should skip excluded pages and unset fields:\n%s", sitemap)
+	}
+}
+
+func TestBuildSitemapSplitsIntoIndex(t *testing.T) {
+	defer func(limit int) { sitemapLimit = limit }(sitemapLimit)
+	sitemapLimit = 2
+
+	outDir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		writeOut(t, filepath.Join(outDir, name, "index.html"), "")
+	}
+	entries := map[string]model.SitemapEntry{
+		"c/index.html": {LastMod: time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)},
+	}
+	if err := BuildSitemap("https://example.com", outDir, "maps/sitemap.xml", entries); err != nil {
+		t.Fatalf("BuildSitemap: %v", err)
+	}
+
+	index := readOut(t, filepath.Join(outDir, "maps", "sitemap.xml"))
+	if !strings.Contains(index, "<sitemapindex") ||
+		!strings.Contains(index, "<loc>https://example.com/maps/sitemap-1.xml</loc>") ||
+		!strings.Contains(index, "<loc>https://example.com/maps/sitemap-2.xml</loc>\n    <lastmod>2024-05-06T00:00:00Z</lastmod>") {
+		t.Fatalf("unexpected sitemap index:\n%s", index)
+	}
+	if part := readOut(t, filepath.Join(outDir, "maps", "sitemap-2.xml")); !strings.Contains(part, "https://example.com/c/") {
+		t.Fatalf("expected the last URL in the second sitemap:\n%s", part)
+	}
+}
@@ -76,3 +76,17 @@ func copyFile(src, dst string) error {
 
 	return dstFile.Sync()
 }
+
+// BuildHighlightCSS writes the stylesheet for class-based syntax highlighting
+// to syntax.css in staticOutDir, unless the site ships its own.
+func BuildHighlightCSS(staticOutDir, css string) error {
+	path := filepath.Join(staticOutDir, "syntax.css")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(staticOutDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(css), 0644)
+}
@@ -5,77 +5,274 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"oojsite/internal/model"
+	"oojsite/internal/parse"
 )
 
 type Config struct {
-	AllDir       string
-	OutDir       string
-	PageDir      string
-	PostDir      string
-	StaticDir    string
-	TemplateDir  string
-	ComponentDir string
-	BaseURL      string
-	Dev          bool
+	AllDir             string
+	OutDir             string
+	PageDir            string
+	PostDir            string
+	StaticDir          string
+	TemplateDir        string
+	ComponentDir       string
+	DataDir            string
+	ArchetypeDir       string
+	BaseURL            string
+	DateFormat         string
+	Permalink          string
+	Redirects          string
+	Dev                bool
+	Drafts             bool
+	Future             bool
+	Expired            bool
+	BuildTime          time.Time
+	Taxonomies         []string
+	Feeds              []string
+	FeedLimit          int
+	FeedContent        bool
+	SectionFeeds       bool
+	TermFeeds          bool
+	CleanGenerated     bool
+	GitLastMod         bool
+	Sitemap            string
+	Environment        string
+	MarkdownExtensions []string
+	Highlight          model.HighlightOptions
+	TOC                model.TOCOptions
+	WordsPerMinute     int
+	SnippetWords       int
+	Params             map[string]interface{}
 }
 
-func Parse() (*Config, error) {
-	cfg := &Config{}
+// Parse registers the site flags on fs, parses args and merges in the config
+// file. Callers may register their own flags on fs beforehand.
+func Parse(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := &Config{MarkdownExtensions: model.DefaultMarkdownExtensions}
+	var configPath string
+
+	fs.StringVar(&configPath, "config", "", "Path to config file (default: oojsite.yaml, oojsite.yml or oojsite.toml in the working directory)")
+	fs.StringVar(&cfg.AllDir, "allDir", "", "Base directory to prepend to other paths (site, posts, templates, components, static, data, archetypes)")
+	fs.StringVar(&cfg.OutDir, "outDir", "out", "Path to generate site in")
+	fs.StringVar(&cfg.PageDir, "pageDir", "site", "Path to pages folder")
+	fs.StringVar(&cfg.PostDir, "postDir", "posts", "Path to posts folder")
+	fs.StringVar(&cfg.StaticDir, "staticDir", "static", "Path to static folder")
+	fs.StringVar(&cfg.TemplateDir, "templateDir", "templates", "Path to templates folder")
+	fs.StringVar(&cfg.ComponentDir, "componentDir", "components", "Path to components folder")
+	fs.StringVar(&cfg.DataDir, "dataDir", "data", "Path to data files folder (YAML, JSON, TOML, CSV)")
+	fs.StringVar(&cfg.ArchetypeDir, "archetypeDir", "archetypes", "Path to archetypes folder used by 'new post'")
+	fs.StringVar(&cfg.BaseURL, "baseUrl", "baseUrl", "Base site URL")
+	fs.StringVar(&cfg.Permalink, "permalink", model.DefaultPermalink, "URL pattern for posts using :year, :month, :day, :section, :path, :slug and :filename")
+	fs.StringVar(&cfg.Redirects, "redirects", "", "Also write aliases as a server-side redirect map: netlify (_redirects) or nginx (redirects.map)")
+	fs.StringVar(&cfg.DateFormat, "dateFormat", "January 2, 2006", "Go layout for dates written by 'new post'")
+	fs.Func("markdownExtensions", "Comma-separated Markdown extensions to enable: tables, strikethrough, autolinks, tasklists, footnotes, definitionlists (default all)", func(value string) error {
+		cfg.MarkdownExtensions = nil
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			cfg.MarkdownExtensions = append(cfg.MarkdownExtensions, name)
+		}
+		return nil
+	})
+	fs.IntVar(&cfg.SnippetWords, "snippetWords", model.DefaultSnippetWords, "Maximum words in generated post snippets")
+	fs.IntVar(&cfg.WordsPerMinute, "wordsPerMinute", model.DefaultWordsPerMinute, "Reading speed used for each post's ReadingTime")
+	fs.IntVar(&cfg.TOC.MinLevel, "tocMinLevel", 2, "Shallowest heading level listed in .TableOfContents")
+	fs.IntVar(&cfg.TOC.MaxLevel, "tocMaxLevel", 3, "Deepest heading level listed in .TableOfContents")
+	fs.BoolVar(&cfg.Highlight.Enabled, "highlight", false, "Syntax highlight fenced code blocks that name a language")
+	fs.StringVar(&cfg.Highlight.Style, "highlightStyle", model.DefaultHighlightStyle, "Chroma style for highlighted code, e.g. github, monokai, dracula")
+	fs.BoolVar(&cfg.Highlight.Classes, "highlightClasses", false, "Use CSS classes for highlighted code and write the stylesheet to static/syntax.css")
+	fs.BoolVar(&cfg.Highlight.LineNumbers, "lineNumbers", false, "Show line numbers in highlighted code blocks")
+	fs.StringVar(&cfg.Environment, "environment", "", "Name of the environment being built for, available to templates as .Site.Environment (default \"development\" with --dev, else \"production\")")
+	fs.BoolVar(&cfg.Dev, "dev", false, "Start development server")
+	fs.BoolVar(&cfg.Drafts, "drafts", false, "Include posts marked draft: true (default true with --dev)")
+	fs.BoolVar(&cfg.Future, "future", false, "Include posts dated after the build time (default true with --dev)")
+	fs.BoolVar(&cfg.Expired, "expired", false, "Include posts whose expiryDate has passed")
+	fs.Func("taxonomies", "Comma-separated frontmatter fields to generate term pages for, e.g. tags,categories", func(value string) error {
+		cfg.Taxonomies = nil
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				cfg.Taxonomies = append(cfg.Taxonomies, name)
+			}
+		}
+		return nil
+	})
+	fs.Func("feeds", "Comma-separated feed formats to generate: rss, atom, json", func(value string) error {
+		cfg.Feeds = nil
+		for _, format := range strings.Split(value, ",") {
+			if format = strings.TrimSpace(format); format == "" {
+				continue
+			}
+			if _, ok := model.FeedFiles[format]; !ok {
+				return fmt.Errorf("unknown feed format %q", format)
+			}
+			cfg.Feeds = append(cfg.Feeds, format)
+		}
+		return nil
+	})
+	fs.IntVar(&cfg.FeedLimit, "feedLimit", 20, "Maximum number of posts per feed (0 for all)")
+	fs.BoolVar(&cfg.FeedContent, "feedContent", false, "Put full post content in feeds instead of the snippet")
+	fs.BoolVar(&cfg.SectionFeeds, "sectionFeeds", false, "Also generate a feed for each post section")
+	fs.BoolVar(&cfg.TermFeeds, "termFeeds", false, "Also generate a feed for each taxonomy term")
+	fs.Func("buildTime", "Time to build the site as of, e.g. 2024-01-15 or 2024-01-15T10:00:00Z (default: now)", func(value string) error {
+		t, ok := parse.Date(value)
+		if !ok {
+			return fmt.Errorf("unrecognised date %q", value)
+		}
+		cfg.BuildTime = t
+		return nil
+	})
+	fs.StringVar(&cfg.Sitemap, "sitemap", "sitemap.xml", "Path of the sitemap inside outDir (empty to skip it)")
+	fs.BoolVar(&cfg.GitLastMod, "gitLastMod", false, "Date pages in the sitemap by their last git commit instead of file modification time")
+	fs.BoolVar(&cfg.CleanGenerated, "cleanGenerated", false, "Only remove files produced by the previous build from outDir, keeping anything else")
 
-	flag.StringVar(&cfg.AllDir, "allDir", "", "Base directory to prepend to other paths (site, posts, templates, components, static)")
-	flag.StringVar(&cfg.OutDir, "outDir", "out", "Path to generate site in")
-	flag.StringVar(&cfg.PageDir, "pageDir", "site", "Path to pages folder")
-	flag.StringVar(&cfg.PostDir, "postDir", "posts", "Path to posts folder")
-	flag.StringVar(&cfg.StaticDir, "staticDir", "static", "Path to static folder")
-	flag.StringVar(&cfg.TemplateDir, "templateDir", "templates", "Path to templates folder")
-	flag.StringVar(&cfg.ComponentDir, "componentDir", "components", "Path to components folder")
-	flag.StringVar(&cfg.BaseURL, "baseUrl", "baseUrl", "Base site URL")
-	flag.BoolVar(&cfg.Dev, "dev", false, "Start development server")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := applyFile(fs, cfg, configPath); err != nil {
+		return nil, err
+	}
 
-	flag.Parse()
+	// Anything set on the command line or in the config file counts as explicit
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
 
-	// Apply allDir prefix to paths that still have their default values
+	// Development builds preview unpublished content unless told otherwise
+	if !explicit["drafts"] {
+		cfg.Drafts = cfg.Dev
+	}
+	if !explicit["future"] {
+		cfg.Future = cfg.Dev
+	}
+	if cfg.Environment == "" {
+		cfg.Environment = "production"
+		if cfg.Dev {
+			cfg.Environment = "development"
+		}
+	}
+
+	// Apply allDir prefix to paths that were not set explicitly
 	if cfg.AllDir != "" {
-		if cfg.PageDir == "site" {
+		if !explicit["pageDir"] {
 			cfg.PageDir = filepath.Join(cfg.AllDir, "site")
 		}
-		if cfg.PostDir == "posts" {
+		if !explicit["postDir"] {
 			cfg.PostDir = filepath.Join(cfg.AllDir, "posts")
 		}
-		if cfg.StaticDir == "static" {
+		if !explicit["staticDir"] {
 			cfg.StaticDir = filepath.Join(cfg.AllDir, "static")
 		}
-		if cfg.TemplateDir == "templates" {
+		if !explicit["templateDir"] {
 			cfg.TemplateDir = filepath.Join(cfg.AllDir, "templates")
 		}
-		if cfg.ComponentDir == "components" {
+		if !explicit["componentDir"] {
 			cfg.ComponentDir = filepath.Join(cfg.AllDir, "components")
 		}
+		if !explicit["dataDir"] {
+			cfg.DataDir = filepath.Join(cfg.AllDir, "data")
+		}
+		if !explicit["archetypeDir"] {
+			cfg.ArchetypeDir = filepath.Join(cfg.AllDir, "archetypes")
+		}
 	}
 
-	if err := validateDirs(cfg); err != nil {
+	if err := validate(cfg); err != nil {
 		return nil, err
 	}
 
 	return cfg, nil
 }
 
+func validate(cfg *Config) error {
+	if cfg.Params == nil {
+		cfg.Params = make(map[string]interface{})
+	}
+	if cfg.SnippetWords < 1 {
+		return fmt.Errorf("snippetWords must be at least 1, got %d", cfg.SnippetWords)
+	}
+	if cfg.WordsPerMinute < 1 {
+		return fmt.Errorf("wordsPerMinute must be at least 1, got %d", cfg.WordsPerMinute)
+	}
+	if cfg.TOC.MinLevel < 1 || cfg.TOC.MaxLevel > 6 || cfg.TOC.MinLevel > cfg.TOC.MaxLevel {
+		return fmt.Errorf("invalid table of contents levels %d to %d (expected 1 to 6)", cfg.TOC.MinLevel, cfg.TOC.MaxLevel)
+	}
+	if cfg.Sitemap != "" {
+		clean := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(cfg.Sitemap, "/")))
+		if clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("sitemap %q must be a file inside outDir", cfg.Sitemap)
+		}
+	}
+	switch cfg.Redirects {
+	case "", "netlify", "nginx":
+	default:
+		return fmt.Errorf("unknown redirects format %q (expected netlify or nginx)", cfg.Redirects)
+	}
+	return validateDirs(cfg)
+}
+
+// validateDirs only inspects the source dirs; CreateDirs makes the missing
+// ones, so commands such as check leave the tree alone.
 func validateDirs(cfg *Config) error {
-	dirs := []string{cfg.OutDir, cfg.PageDir, cfg.PostDir, cfg.StaticDir, cfg.TemplateDir, cfg.ComponentDir}
+	for _, path := range cfg.SourceDirs() {
+		if err := checkDir(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	for _, path := range dirs {
-		if err := ensureDir(path); err != nil {
-			return fmt.Errorf("failed to prepare directory %s: %w", path, err)
+// SourceDirs lists every input directory. Optional ones, such as the data
+// and archetype dirs, may not exist.
+func (cfg *Config) SourceDirs() []string {
+	var dirs []string
+	for _, dir := range cfg.requiredDirs() {
+		dirs = append(dirs, *dir)
+	}
+	return append(dirs, cfg.DataDir, cfg.ArchetypeDir)
+}
+
+func (cfg *Config) requiredDirs() []*string {
+	return []*string{&cfg.PageDir, &cfg.PostDir, &cfg.StaticDir, &cfg.TemplateDir, &cfg.ComponentDir}
+}
+
+// CreateDirs creates any missing source dirs a build reads from.
+func (cfg *Config) CreateDirs() error {
+	for _, dir := range cfg.requiredDirs() {
+		if err := os.MkdirAll(*dir, 0755); err != nil {
+			return fmt.Errorf("failed to prepare directory %s: %w", *dir, err)
 		}
 	}
+	return nil
+}
 
-	return os.RemoveAll(cfg.OutDir)
+// StandInDirs points missing source dirs at empty ones under root, so the
+// site can be built without creating anything in its tree.
+func (cfg *Config) StandInDirs(root string) error {
+	for _, dir := range cfg.requiredDirs() {
+		if _, err := os.Stat(*dir); !os.IsNotExist(err) {
+			continue
+		}
+		standIn, err := os.MkdirTemp(root, filepath.Base(*dir)+"-")
+		if err != nil {
+			return err
+		}
+		*dir = standIn
+	}
+	return nil
 }
 
-func ensureDir(path string) error {
+// checkDir accepts a missing path, but not one that isn't a directory.
+func checkDir(path string) error {
 	stat, err := os.Stat(path)
 	if os.IsNotExist(err) {
-		return os.MkdirAll(path, 0755)
+		return nil
 	}
 	if err != nil {
 		return err
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseMergesConfigFileWithFlags(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, "oojsite.yaml")
+	writeFile(t, configPath, "allDir: "+root+"\noutDir: "+filepath.Join(root, "public")+"\nbaseUrl: https://file.example\npostDir: "+filepath.Join(root, "content")+"\nparams:\n  author: Jane\n  social:\n    github: jane\n")
+
+	cfg, err := Parse(flag.NewFlagSet("test", flag.ContinueOnError), []string{"--config", configPath, "--baseUrl", "https://flag.example"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	if cfg.BaseURL != "https://flag.example" {
+		t.Fatalf("expected flag to override file baseUrl, got %q", cfg.BaseURL)
+	}
+	if cfg.OutDir != filepath.Join(root, "public") {
+		t.Fatalf("expected outDir from file, got %q", cfg.OutDir)
+	}
+	if cfg.PostDir != filepath.Join(root, "content") {
+		t.Fatalf("expected explicit postDir to skip allDir prefix, got %q", cfg.PostDir)
+	}
+	if cfg.PageDir != filepath.Join(root, "site") {
+		t.Fatalf("expected allDir prefix on pageDir, got %q", cfg.PageDir)
+	}
+	if cfg.Params["author"] != "Jane" {
+		t.Fatalf("expected author param, got %v", cfg.Params["author"])
+	}
+	social, ok := cfg.Params["social"].(map[string]interface{})
+	if !ok || social["github"] != "jane" {
+		t.Fatalf("expected nested params to be normalized, got %#v", cfg.Params["social"])
+	}
+}
+
+func TestParseReadsTOMLConfig(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, "oojsite.toml")
+	writeFile(t, configPath, "allDir = \""+filepath.ToSlash(root)+"\"\noutDir = \""+filepath.ToSlash(filepath.Join(root, "out"))+"\"\n\n[params]\ntitle = \"My Site\"\n")
+
+	cfg, err := Parse(flag.NewFlagSet("test", flag.ContinueOnError), []string{"--config", configPath})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cfg.Params["title"] != "My Site" {
+		t.Fatalf("expected title param, got %v", cfg.Params["title"])
+	}
+}
+
+func TestParseReadsTOMLDates(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, "oojsite.toml")
+	writeFile(t, configPath, "allDir = \""+filepath.ToSlash(root)+"\"\nbuildTime = 2024-01-15\n")
+
+	cfg, err := Parse(flag.NewFlagSet("test", flag.ContinueOnError), []string{"--config", configPath})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if want := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC); !cfg.BuildTime.Equal(want) {
+		t.Fatalf("expected build time %v, got %v", want, cfg.BuildTime)
+	}
+}
+
+func TestParseDefaultsDraftsToDevMode(t *testing.T) {
+	root := t.TempDir()
+	base := []string{"--allDir", root, "--outDir", filepath.Join(root, "out")}
+
+	cfg, err := Parse(flag.NewFlagSet("test", flag.ContinueOnError), append(base, "--dev"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !cfg.Drafts {
+		t.Fatal("expected drafts to be included in dev mode")
+	}
+
+	cfg, err = Parse(flag.NewFlagSet("test", flag.ContinueOnError), append(base, "--dev", "--drafts=false"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cfg.Drafts {
+		t.Fatal("expected explicit --drafts=false to win over dev mode")
+	}
+
+	cfg, err = Parse(flag.NewFlagSet("test", flag.ContinueOnError), base)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cfg.Drafts {
+		t.Fatal("expected drafts to be excluded from normal builds")
+	}
+}
+
+func TestParseLeavesOptionalDirsAlone(t *testing.T) {
+	root := t.TempDir()
+	if _, err := Parse(flag.NewFlagSet("test", flag.ContinueOnError), []string{"--allDir", root, "--outDir", filepath.Join(root, "out")}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	for _, dir := range []string{"data", "archetypes"} {
+		if _, err := os.Stat(filepath.Join(root, dir)); !os.IsNotExist(err) {
+			t.Fatalf("expected no %s dir to be created, got %v", dir, err)
+		}
+	}
+
+	writeFile(t, filepath.Join(root, "data"), "not a directory")
+	if _, err := Parse(flag.NewFlagSet("test", flag.ContinueOnError), []string{"--allDir", root, "--outDir", filepath.Join(root, "out")}); err == nil {
+		t.Fatal("expected a data file in place of the data dir to be rejected")
+	}
+}
+
+func TestParseRejectsSitemapOutsideOutDir(t *testing.T) {
+	root := t.TempDir()
+	base := []string{"--allDir", root, "--outDir", filepath.Join(root, "out")}
+
+	for _, sitemap := range []string{"../sitemap.xml", "maps/../../x.xml", "/"} {
+		if _, err := Parse(flag.NewFlagSet("test", flag.ContinueOnError), append(base, "--sitemap", sitemap)); err == nil {
+			t.Fatalf("expected sitemap %q to be rejected", sitemap)
+		}
+	}
+	if _, err := Parse(flag.NewFlagSet("test", flag.ContinueOnError), append(base, "--sitemap", "/maps/sitemap.xml")); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+}
+
+func TestParseRejectsUnknownConfigKeys(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, "oojsite.yaml")
+	writeFile(t, configPath, "postsDir: posts\n")
+
+	if _, err := Parse(flag.NewFlagSet("test", flag.ContinueOnError), []string{"--config", configPath}); err == nil {
+		t.Fatal("expected unknown config key to be rejected")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
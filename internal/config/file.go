@@ -0,0 +1,118 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"oojsite/internal/parse"
+)
+
+var defaultConfigFiles = []string{"oojsite.yaml", "oojsite.yml", "oojsite.toml"}
+
+// applyFile loads the config file and feeds its values through the flag set,
+// so file keys share names, parsing and defaults with the flags. Flags given
+// on the command line always win.
+func applyFile(fs *flag.FlagSet, cfg *Config, path string) error {
+	if path == "" {
+		path = findConfigFile()
+		if path == "" {
+			return nil
+		}
+	}
+
+	values, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	fromArgs := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		fromArgs[f.Name] = true
+	})
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		if key == "params" {
+			params, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: params must be a map", path)
+			}
+			cfg.Params = params
+			continue
+		}
+		if key == "config" || fs.Lookup(key) == nil {
+			return fmt.Errorf("%s: unknown config key %q", path, key)
+		}
+		if fromArgs[key] {
+			continue
+		}
+		if err := fs.Set(key, flagValue(value)); err != nil {
+			return fmt.Errorf("%s: invalid value for %q: %w", path, key, err)
+		}
+	}
+
+	return nil
+}
+
+func findConfigFile() string {
+	for _, name := range defaultConfigFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+func readConfigFile(path string) (map[string]interface{}, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".toml":
+	default:
+		return nil, errors.New("config file must be .yaml, .yml or .toml: " + path)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	value, err := parse.DataFile(path, raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if value == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config file %s must contain a map of settings", path)
+	}
+	return values, nil
+}
+
+func flagValue(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = flagValue(item)
+		}
+		return strings.Join(items, ",")
+	case time.Time:
+		// TOML dates and times arrive decoded
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,94 @@
+package content
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"oojsite/internal/model"
+)
+
+var aliasTmpl = template.Must(template.New("alias").Parse(`<!DOCTYPE html>
+<html lang="en">
+
+<head>
+    <meta charset="UTF-8" />
+    <title>{{ . }}</title>
+    <link rel="canonical" href="{{ . }}" />
+    <meta name="robots" content="noindex" />
+    <meta http-equiv="refresh" content="0; url={{ . }}" />
+</head>
+
+<body>
+    <p>This page has moved to <a href="{{ . }}">{{ . }}</a>.</p>
+</body>
+
+</html>
+`))
+
+// RenderAliases writes a redirect page at every alias of every post and, when
+// format is "netlify" or "nginx", a server-side redirect map alongside. It must
+// run after everything else is rendered so aliases can't shadow real pages. It
+// returns the redirect pages written, relative to outDir.
+func RenderAliases(posts []model.Post, site model.Site, outDir, format string) ([]string, error) {
+	var written []string
+	redirects := make(map[string]string)
+	for _, post := range posts {
+		target := model.AbsURL(site.BaseURL, post.Filepath)
+		for _, alias := range post.Aliases {
+			rel := outputFile(alias)
+			outPath := filepath.Join(outDir, filepath.FromSlash(rel))
+			if _, err := os.Stat(outPath); err == nil {
+				return nil, fmt.Errorf("%s: alias %s overlaps an existing page", post.SourcePath, alias)
+			}
+			redirects[alias] = post.Filepath
+
+			if err := executeTo(outPath, aliasTmpl, target); err != nil {
+				return nil, fmt.Errorf("%s: alias %s: %w", post.SourcePath, alias, err)
+			}
+			written = append(written, rel)
+		}
+	}
+
+	return written, writeRedirectMap(redirects, outDir, format)
+}
+
+func writeRedirectMap(redirects map[string]string, outDir, format string) error {
+	var name, line string
+	switch format {
+	case "":
+		return nil
+	case "netlify":
+		// Forced, as Netlify otherwise serves the redirect page at the alias
+		name, line = "_redirects", "%s %s 301!\n"
+	case "nginx":
+		name, line = "redirects.map", "%s %s;\n"
+	default:
+		return fmt.Errorf("unknown redirects format %q (expected netlify or nginx)", format)
+	}
+
+	from := make([]string, 0, len(redirects))
+	for alias := range redirects {
+		from = append(from, alias)
+	}
+	sort.Strings(from)
+
+	var b strings.Builder
+	for _, alias := range from {
+		fmt.Fprintf(&b, line, alias, redirects[alias])
+	}
+	return os.WriteFile(filepath.Join(outDir, name), []byte(b.String()), 0644)
+}
+
+func parseAliases(value interface{}) []string {
+	var aliases []string
+	for _, alias := range termValues(value) {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, cleanURL(alias))
+		}
+	}
+	return aliases
+}
@@ -0,0 +1,96 @@
+package content
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"oojsite/internal/model"
+)
+
+func TestRenderAliasesWritesRedirects(t *testing.T) {
+	outDir := t.TempDir()
+	posts := []model.Post{{
+		SourcePath: "posts/new.md",
+		Filepath:   "/posts/new/",
+		Aliases:    parseAliases([]interface{}{"/posts/old/", "legacy.html"}),
+	}}
+	site := model.Site{BaseURL: "https://example.com/"}
+
+	written, err := RenderAliases(posts, site, outDir, "netlify")
+	if err != nil {
+		t.Fatalf("RenderAliases: %v", err)
+	}
+	if strings.Join(written, ",") != "posts/old/index.html,legacy.html" {
+		t.Fatalf("unexpected redirect pages: %v", written)
+	}
+
+	page := readFile(t, filepath.Join(outDir, "posts", "old", "index.html"))
+	if !strings.Contains(page, `<link rel="canonical" href="https://example.com/posts/new/" />`) ||
+		!strings.Contains(page, `content="0; url=https://example.com/posts/new/"`) {
+		t.Fatalf("redirect page missing canonical link or refresh:\n%s", page)
+	}
+
+}
+
+func TestRenderAliasesRedirectFormats(t *testing.T) {
+	posts := []model.Post{{
+		SourcePath: "posts/new.md",
+		Filepath:   "/posts/new/",
+		Aliases:    parseAliases([]interface{}{"/posts/old/", "legacy.html"}),
+	}}
+	site := model.Site{BaseURL: "https://example.com/"}
+
+	cases := []struct {
+		format string
+		file   string
+		want   string
+	}{
+		{"", "", ""},
+		{"netlify", "_redirects", "/legacy.html /posts/new/ 301!\n/posts/old/ /posts/new/ 301!\n"},
+		{"nginx", "redirects.map", "/legacy.html /posts/new/;\n/posts/old/ /posts/new/;\n"},
+	}
+	for _, c := range cases {
+		outDir := t.TempDir()
+		if _, err := RenderAliases(posts, site, outDir, c.format); err != nil {
+			t.Fatalf("RenderAliases(%q): %v", c.format, err)
+		}
+
+		entries, err := os.ReadDir(outDir)
+		if err != nil {
+			t.Fatalf("read %s: %v", outDir, err)
+		}
+		var files []string
+		for _, entry := range entries {
+			if !entry.IsDir() && entry.Name() != "legacy.html" {
+				files = append(files, entry.Name())
+			}
+		}
+		if c.file == "" {
+			if len(files) != 0 {
+				t.Fatalf("expected no redirect map without a format, got %v", files)
+			}
+			continue
+		}
+		if len(files) != 1 || files[0] != c.file {
+			t.Fatalf("%s: expected only %s, got %v", c.format, c.file, files)
+		}
+		if got := readFile(t, filepath.Join(outDir, c.file)); got != c.want {
+			t.Fatalf("%s: unexpected %s:\n%s", c.format, c.file, got)
+		}
+	}
+}
+
+func TestRenderAliasesRejectsExistingPages(t *testing.T) {
+	outDir := t.TempDir()
+	writeFile(t, filepath.Join(outDir, "about", "index.html"), "<p>about</p>")
+	posts := []model.Post{{SourcePath: "posts/a.md", Filepath: "/posts/a/", Aliases: []string{"/about/"}}}
+
+	if _, err := RenderAliases(posts, model.Site{}, outDir, ""); err == nil {
+		t.Fatal("expected an alias over an existing page to be rejected")
+	}
+	if got := readFile(t, filepath.Join(outDir, "about", "index.html")); got != "<p>about</p>" {
+		t.Fatalf("existing page was overwritten: %s", got)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kaleocheng/goldmark"
 	"github.com/kaleocheng/goldmark/ast"
@@ -15,20 +17,74 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"oojsite/internal/model"
+	"oojsite/internal/parse"
 )
 
-func LoadPosts(postDir string) ([]model.Post, error) {
+type LoadOptions struct {
+	Drafts    bool
+	Future    bool
+	Expired   bool
+	BuildTime time.Time
+	Permalink string
+	// GitLastMod takes a post's last modified time from its last commit
+	// rather than the file's mtime when frontmatter doesn't give one.
+	GitLastMod bool
+	// Markdown parses post bodies for snippets; it should be the converter
+	// posts are rendered with. Defaults to plain CommonMark.
+	Markdown *Markdown
+	// WordsPerMinute sets the reading speed for ReadingTime. Defaults to
+	// model.DefaultWordsPerMinute.
+	WordsPerMinute int
+	// SnippetWords caps generated snippets. Defaults to model.DefaultSnippetWords.
+	SnippetWords int
+	// DateFormat is the site's date layout, tried before the built-in ones.
+	DateFormat string
+}
+
+func LoadPosts(postDir string, opts LoadOptions) ([]model.Post, error) {
 	var posts []model.Post
+	if opts.BuildTime.IsZero() {
+		opts.BuildTime = time.Now()
+	}
+	if opts.Permalink == "" {
+		opts.Permalink = model.DefaultPermalink
+	}
+	if opts.SnippetWords <= 0 {
+		opts.SnippetWords = model.DefaultSnippetWords
+	}
+	if opts.WordsPerMinute <= 0 {
+		opts.WordsPerMinute = model.DefaultWordsPerMinute
+	}
+	if opts.Markdown == nil {
+		opts.Markdown = &Markdown{Markdown: goldmark.New()}
+	}
+	outputs := make(map[string]string)
+	times, err := newModTimes(opts.GitLastMod, postDir)
+	if err != nil {
+		return nil, err
+	}
 
-	err := filepath.Walk(postDir, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(postDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".md") {
 			return err
 		}
 
-		post, err := loadPost(path, postDir)
+		post, err := loadPost(path, opts)
 		if err != nil {
 			return err
 		}
+		if !opts.includes(post) {
+			return nil
+		}
+		if err := setPermalink(post, postDir, opts.Permalink); err != nil {
+			return err
+		}
+		if other, dup := outputs[post.OutputRel]; dup {
+			return fmt.Errorf("%s and %s both resolve to %s", other, path, post.Filepath)
+		}
+		outputs[post.OutputRel] = path
+		post.LastMod = postLastMod(post, times, opts.DateFormat)
+		post.ReadingTime = readingTime(post.WordCount, opts.WordsPerMinute)
 
 		posts = append(posts, *post)
 		return nil
@@ -44,18 +100,32 @@ func LoadPosts(postDir string) ([]model.Post, error) {
 	return posts, nil
 }
 
-func RenderPosts(posts []model.Post, outDir string, tmpls *template.Template) error {
+func (opts LoadOptions) includes(post *model.Post) bool {
+	if post.Draft && !opts.Drafts {
+		return false
+	}
+	if !opts.Future && post.Date.After(opts.BuildTime) {
+		return false
+	}
+	if expiry := postDate(post, "expiryDate", opts.DateFormat); !expiry.IsZero() && !opts.Expired && !expiry.After(opts.BuildTime) {
+		return false
+	}
+	return true
+}
+
+func RenderPosts(global model.GlobalData, site model.Site, outDir string, tmpls *template.Template, md *Markdown) error {
+	posts := global.Posts
 	for i := range posts {
-		content, err := renderPost(posts[i], posts, outDir, tmpls)
+		content, err := renderPost(posts[i], global, site, outDir, tmpls, md)
 		if err != nil {
-			return err
+			return fmt.Errorf("%s: %w", posts[i].SourcePath, err)
 		}
 		posts[i].Content = content
 	}
 	return nil
 }
 
-func RenderPages(pageDir, outDir string, posts []model.Post, tmpls *template.Template) error {
+func RenderPages(pageDir, outDir string, global model.GlobalData, site model.Site, tmpls *template.Template) error {
 	return filepath.Walk(pageDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".html") {
 			return err
@@ -66,11 +136,14 @@ func RenderPages(pageDir, outDir string, posts []model.Post, tmpls *template.Tem
 			return err
 		}
 
-		return renderPage(rel, outDir, posts, tmpls)
+		if err := renderPage(rel, outDir, global, site, tmpls); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return nil
 	})
 }
 
-func loadPost(path, postDir string) (*model.Post, error) {
+func loadPost(path string, opts LoadOptions) (*model.Post, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -80,25 +153,24 @@ func loadPost(path, postDir string) (*model.Post, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	rel, err := filepath.Rel(postDir, path)
-	if err != nil {
-		return nil, err
+	if err := summarize(post, opts.Markdown, opts.SnippetWords); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
+
 	post.SourcePath = path
-	post.OutputRel = strings.TrimSuffix(rel, filepath.Ext(rel))
-	post.Filepath = "/posts/" + filepath.ToSlash(post.OutputRel) + "/"
+	post.Draft = isTrue(post.Frontmatter["draft"])
+	post.Date = postDate(post, "date", opts.DateFormat)
+	post.Aliases = parseAliases(post.Frontmatter["aliases"])
 	return post, nil
 }
 
-func renderPost(post model.Post, posts []model.Post, outDir string, tmpls *template.Template) (template.HTML, error) {
-	md := goldmark.New()
-	var buf bytes.Buffer
-	if err := md.Convert(post.Raw, &buf); err != nil {
+func renderPost(post model.Post, global model.GlobalData, site model.Site, outDir string, tmpls *template.Template, md *Markdown) (template.HTML, error) {
+	content, toc, err := md.render(post.Raw)
+	if err != nil {
 		return "", err
 	}
 
-	outPath := filepath.Join(outDir, "posts", post.OutputRel, "index.html")
+	outPath := filepath.Join(outDir, filepath.FromSlash(post.OutputRel))
 	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
 		return "", err
 	}
@@ -110,8 +182,8 @@ func renderPost(post model.Post, posts []model.Post, outDir string, tmpls *templ
 	defer outFile.Close()
 
 	if userTemplate, ok := post.Frontmatter["template"]; !ok || userTemplate == "" {
-		_, err := outFile.Write(buf.Bytes())
-		return template.HTML(buf.String()), err
+		_, err := outFile.WriteString(string(content))
+		return content, err
 	}
 
 	templateName := ""
@@ -126,9 +198,11 @@ func renderPost(post model.Post, posts []model.Post, outDir string, tmpls *templ
 	}
 
 	data := model.TemplateData{
-		Frontmatter: post.Frontmatter,
-		Content:     template.HTML(buf.String()),
-		Global:      model.GlobalData{Posts: posts},
+		Content:         content,
+		TableOfContents: toc,
+		Frontmatter:     post.Frontmatter,
+		Global:          global,
+		Site:            site,
 	}
 
 	selected := tmpls.Lookup(templateName)
@@ -138,27 +212,65 @@ func renderPost(post model.Post, posts []model.Post, outDir string, tmpls *templ
 	if err := selected.Execute(outFile, data); err != nil {
 		return "", err
 	}
-	return template.HTML(buf.String()), nil
+	return content, nil
 }
 
-func renderPage(path, outDir string, posts []model.Post, tmpls *template.Template) error {
-	outPath := filepath.Join(outDir, path)
-	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
-		return err
-	}
-
+func renderPage(path, outDir string, global model.GlobalData, site model.Site, tmpls *template.Template) error {
 	tmpl := tmpls.Lookup(path)
 	if tmpl == nil {
 		return fmt.Errorf("template %s not found", path)
 	}
 
+	urlFor := func(n int) string {
+		_, url := pagePaths(path, n)
+		return url
+	}
+
+	for n, total := 1, 1; n <= total; n++ {
+		data := &pageContext{
+			PageData: model.PageData{Global: global, Site: site},
+			page:     n,
+			urlFor:   urlFor,
+		}
+
+		rel, _ := pagePaths(path, n)
+		outPath := filepath.Join(outDir, filepath.FromSlash(rel))
+		if err := checkUnclaimed(outDir, outPath); err != nil {
+			return err
+		}
+		if err := executeTo(outPath, tmpl, data); err != nil {
+			return err
+		}
+
+		if data.Paginator != nil {
+			total = data.Paginator.TotalPages
+		}
+	}
+	return nil
+}
+
+// checkUnclaimed fails when an earlier step already wrote outPath. Posts are
+// rendered first, then taxonomies, then pages, so a later one can't silently
+// replace an earlier one.
+func checkUnclaimed(outDir, outPath string) error {
+	if _, err := os.Stat(outPath); err == nil {
+		rel, _ := filepath.Rel(outDir, outPath)
+		return fmt.Errorf("%s overlaps a post or taxonomy page at the same URL", filepath.ToSlash(rel))
+	}
+	return nil
+}
+
+func executeTo(outPath string, tmpl *template.Template, data any) error {
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return err
+	}
+
 	outFile, err := os.Create(outPath)
 	if err != nil {
 		return err
 	}
 	defer outFile.Close()
 
-	data := model.PageData{Global: model.GlobalData{Posts: posts}}
 	return tmpl.Execute(outFile, data)
 }
 
@@ -185,39 +297,49 @@ func extractFrontmatter(path string, content []byte) (*model.Post, error) {
 	if frontmatter == nil {
 		frontmatter = make(map[string]interface{})
 	}
+	frontmatter = parse.Normalize(frontmatter).(map[string]interface{})
 
 	return &model.Post{
 		Frontmatter: frontmatter,
-		Snippet:     makeSnippet(rawBody, 20),
 		Raw:         rawBody,
 	}, nil
 }
 
-func makeSnippet(raw []byte, wordCount int) string {
-	text := extractText(raw)
-	words := strings.Fields(text)
-	if len(words) == 0 {
-		return ""
-	}
-	if len(words) <= wordCount {
-		return strings.Join(words, " ")
+func isTrue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		return err == nil && b
 	}
-	return strings.Join(words[:wordCount], " ") + "..."
+	return false
+}
+
+// readingTime rounds up to whole minutes, so any text takes at least one.
+func readingTime(words, perMinute int) int {
+	return (words + perMinute - 1) / perMinute
 }
 
-func extractText(raw []byte) string {
-	doc := goldmark.DefaultParser().Parse(text.NewReader(raw))
+func extractText(md *Markdown, raw []byte) string {
+	doc := md.Parser().Parse(text.NewReader(raw))
 	var b strings.Builder
 
 	var walk func(ast.Node)
 	walk = func(n ast.Node) {
 		if t, ok := n.(*ast.Text); ok {
+			// Inline text runs on, so "*words*." stays one word
 			b.Write(t.Segment.Value(raw))
-			b.WriteByte(' ')
+			if t.SoftLineBreak() || t.HardLineBreak() {
+				b.WriteByte(' ')
+			}
 		}
 		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
 			walk(c)
 		}
+		if n.Type() == ast.TypeBlock {
+			b.WriteByte(' ')
+		}
 	}
 
 	walk(doc)
@@ -1,12 +1,18 @@
 package content
 
 import (
+	"bytes"
 	"html/template"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/kaleocheng/goldmark"
+
+	"oojsite/internal/model"
 	"oojsite/internal/templates"
 )
 
@@ -23,12 +29,12 @@ func TestRenderPostsWithoutTemplate(t *testing.T) {
 	postPath := filepath.Join(postsDir, "blog", "hello.md")
 	writeFile(t, postPath, "---\ntitle: Hello\n---\n# Hello\n\nThis is markdown.")
 
-	posts, err := LoadPosts(postsDir)
+	posts, err := LoadPosts(postsDir, LoadOptions{})
 	if err != nil {
 		t.Fatalf("LoadPosts: %v", err)
 	}
 
-	if err := RenderPosts(posts, outDir, template.New("")); err != nil {
+	if err := RenderPosts(model.GlobalData{Posts: posts}, model.Site{}, outDir, template.New(""), &Markdown{Markdown: goldmark.New()}); err != nil {
 		t.Fatalf("RenderPosts: %v", err)
 	}
 
@@ -63,12 +69,12 @@ func TestRenderPostsWithTemplate(t *testing.T) {
 		t.Fatalf("templates.Load: %v", err)
 	}
 
-	posts, err := LoadPosts(postsDir)
+	posts, err := LoadPosts(postsDir, LoadOptions{})
 	if err != nil {
 		t.Fatalf("LoadPosts: %v", err)
 	}
 
-	if err := RenderPosts(posts, outDir, tmpls); err != nil {
+	if err := RenderPosts(model.GlobalData{Posts: posts}, model.Site{}, outDir, tmpls, &Markdown{Markdown: goldmark.New()}); err != nil {
 		t.Fatalf("RenderPosts: %v", err)
 	}
 
@@ -93,12 +99,12 @@ func TestRenderPostsWithoutFrontmatter(t *testing.T) {
 
 	writeFile(t, filepath.Join(postsDir, "plain.md"), "# Plain\n\nNo frontmatter here.")
 
-	posts, err := LoadPosts(postsDir)
+	posts, err := LoadPosts(postsDir, LoadOptions{})
 	if err != nil {
 		t.Fatalf("LoadPosts: %v", err)
 	}
 
-	if err := RenderPosts(posts, outDir, template.New("")); err != nil {
+	if err := RenderPosts(model.GlobalData{Posts: posts}, model.Site{}, outDir, template.New(""), &Markdown{Markdown: goldmark.New()}); err != nil {
 		t.Fatalf("RenderPosts: %v", err)
 	}
 
@@ -108,6 +114,114 @@ func TestRenderPostsWithoutFrontmatter(t *testing.T) {
 	}
 }
 
+func TestRenderPagesExposesSite(t *testing.T) {
+	root := t.TempDir()
+	tmplDir := filepath.Join(root, "templates")
+	componentDir := filepath.Join(root, "components")
+	siteDir := filepath.Join(root, "site")
+	outDir := filepath.Join(root, "out")
+	for _, dir := range []string{tmplDir, componentDir, siteDir, outDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("mkdir %s: %v", dir, err)
+		}
+	}
+
+	writeFile(t, filepath.Join(siteDir, "index.html"), `{{ .Site.BaseURL }}|{{ .Site.Params.author }}`)
+
+	tmpls, err := templates.Load(tmplDir, componentDir, siteDir)
+	if err != nil {
+		t.Fatalf("templates.Load: %v", err)
+	}
+
+	site := model.Site{BaseURL: "https://example.com", Params: map[string]interface{}{"author": "Jane"}}
+	if err := RenderPages(siteDir, outDir, model.GlobalData{}, site, tmpls); err != nil {
+		t.Fatalf("RenderPages: %v", err)
+	}
+
+	if output := readFile(t, filepath.Join(outDir, "index.html")); output != "https://example.com|Jane" {
+		t.Fatalf("unexpected site data in page output: %s", output)
+	}
+}
+
+func TestLoadPostsExcludesDrafts(t *testing.T) {
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(postsDir, "published.md"), "---\ntitle: Published\n---\nbody")
+	writeFile(t, filepath.Join(postsDir, "draft.md"), "---\ntitle: Draft\ndraft: true\n---\nbody")
+	writeFile(t, filepath.Join(postsDir, "quoted.md"), "---\ntitle: Quoted\ndraft: \"true\"\n---\nbody")
+
+	posts, err := LoadPosts(postsDir, LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadPosts: %v", err)
+	}
+	if len(posts) != 1 || posts[0].Frontmatter["title"] != "Published" {
+		t.Fatalf("expected only the published post, got %d posts", len(posts))
+	}
+
+	posts, err = LoadPosts(postsDir, LoadOptions{Drafts: true})
+	if err != nil {
+		t.Fatalf("LoadPosts: %v", err)
+	}
+	if len(posts) != 3 {
+		t.Fatalf("expected drafts to be included, got %d posts", len(posts))
+	}
+	if !posts[0].Draft || posts[1].Draft {
+		t.Fatalf("expected Draft to be set from frontmatter")
+	}
+}
+
+func TestLoadPostsSchedulesByBuildTime(t *testing.T) {
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(postsDir, "past.md"), "---\ndate: January 1, 2024\n---\nbody")
+	writeFile(t, filepath.Join(postsDir, "scheduled.md"), "---\ndate: 2024-03-01\n---\nbody")
+	writeFile(t, filepath.Join(postsDir, "notice.md"), "---\ndate: 2024-01-01\nexpiryDate: 2024-02-01T00:00:00Z\n---\nbody")
+
+	buildTime := time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)
+	titles := func(opts LoadOptions) []string {
+		t.Helper()
+		opts.BuildTime = buildTime
+		posts, err := LoadPosts(postsDir, opts)
+		if err != nil {
+			t.Fatalf("LoadPosts: %v", err)
+		}
+		var names []string
+		for _, post := range posts {
+			names = append(names, strings.TrimSuffix(filepath.Base(post.SourcePath), ".md"))
+		}
+		return names
+	}
+
+	if got := strings.Join(titles(LoadOptions{}), ","); got != "past" {
+		t.Fatalf("expected only past post, got %q", got)
+	}
+	if got := strings.Join(titles(LoadOptions{Future: true}), ","); got != "past,scheduled" {
+		t.Fatalf("expected future post to be included, got %q", got)
+	}
+	if got := strings.Join(titles(LoadOptions{Expired: true}), ","); got != "notice,past" {
+		t.Fatalf("expected expired post to be included, got %q", got)
+	}
+}
+
+func TestLoadPostsReadsSiteDateFormat(t *testing.T) {
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(postsDir, "later.md"), "---\ndate: 18/10/2099\n---\nbody")
+	writeFile(t, filepath.Join(postsDir, "odd.md"), "---\ndate: sometime soon\n---\nbody")
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	posts, err := LoadPosts(postsDir, LoadOptions{BuildTime: time.Now(), DateFormat: "02/01/2006"})
+	if err != nil {
+		t.Fatalf("LoadPosts: %v", err)
+	}
+	if len(posts) != 1 || filepath.Base(posts[0].SourcePath) != "odd.md" {
+		t.Fatalf("expected the 2099 post to be held back, got %+v", posts)
+	}
+	if !strings.Contains(logs.String(), `unrecognised date "sometime soon"`) {
+		t.Fatalf("expected a warning for the unreadable date, got %q", logs.String())
+	}
+}
+
 func TestLoadPostsRejectsMalformedFrontmatter(t *testing.T) {
 	root := t.TempDir()
 	postsDir := filepath.Join(root, "posts")
@@ -117,7 +231,7 @@ func TestLoadPostsRejectsMalformedFrontmatter(t *testing.T) {
 
 	writeFile(t, filepath.Join(postsDir, "broken.md"), "---\ntitle: missing end marker")
 
-	if _, err := LoadPosts(postsDir); err == nil {
+	if _, err := LoadPosts(postsDir, LoadOptions{}); err == nil {
 		t.Fatal("expected LoadPosts to fail for malformed frontmatter")
 	}
 }
@@ -140,3 +254,22 @@ func readFile(t *testing.T, path string) string {
 	}
 	return string(data)
 }
+
+func TestLoadPostsCountsWords(t *testing.T) {
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(postsDir, "short.md"), "---\ntitle: Not counted\n---\n# Heading\n\nThree more *words*.")
+	writeFile(t, filepath.Join(postsDir, "long.md"), strings.Repeat("word ", 250))
+
+	posts, err := LoadPosts(postsDir, LoadOptions{BuildTime: time.Now(), WordsPerMinute: 100})
+	if err != nil {
+		t.Fatalf("LoadPosts: %v", err)
+	}
+
+	expected := map[string][2]int{"long.md": {250, 3}, "short.md": {4, 1}}
+	for _, post := range posts {
+		want := expected[filepath.Base(post.SourcePath)]
+		if post.WordCount != want[0] || post.ReadingTime != want[1] {
+			t.Fatalf("%s: expected %d words and %d min, got %d and %d", post.SourcePath, want[0], want[1], post.WordCount, post.ReadingTime)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package content
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"oojsite/internal/parse"
+)
+
+var dataExts = map[string]bool{".yaml": true, ".yml": true, ".json": true, ".toml": true, ".csv": true}
+
+// LoadData parses every data file under dataDir into a tree keyed by path,
+// so data/nav/main.yaml ends up at .Global.Data.nav.main.
+func LoadData(dataDir string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
+		return data, nil
+	}
+
+	err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !dataExts[strings.ToLower(filepath.Ext(path))] {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		value, err := parse.DataFile(path, content)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dataDir, path)
+		if err != nil {
+			return err
+		}
+		keys := strings.Split(filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel))), "/")
+
+		return insertData(data, keys, value, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func insertData(data map[string]interface{}, keys []string, value interface{}, path string) error {
+	node := data
+	for _, key := range keys[:len(keys)-1] {
+		switch existing := node[key].(type) {
+		case nil:
+			child := make(map[string]interface{})
+			node[key] = child
+			node = child
+		case map[string]interface{}:
+			node = existing
+		default:
+			return fmt.Errorf("data file %s conflicts with existing key %q", path, key)
+		}
+	}
+
+	last := keys[len(keys)-1]
+	existing, exists := node[last]
+	if !exists {
+		node[last] = value
+		return nil
+	}
+
+	// A file and a directory with the same name (nav.yaml and nav/) merge,
+	// as long as the file holds a map.
+	existingMap, okExisting := existing.(map[string]interface{})
+	valueMap, okValue := value.(map[string]interface{})
+	if !okExisting || !okValue {
+		return fmt.Errorf("data file %s conflicts with existing key %q", path, last)
+	}
+	for key, item := range valueMap {
+		if _, dup := existingMap[key]; dup {
+			return fmt.Errorf("data file %s conflicts with existing key %q", path, key)
+		}
+		existingMap[key] = item
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package content
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDataBuildsNestedTree(t *testing.T) {
+	dataDir := t.TempDir()
+	writeFile(t, filepath.Join(dataDir, "nav", "main.yaml"), "- title: Home\n  url: /\n- title: Blog\n  url: /blog/\n")
+	writeFile(t, filepath.Join(dataDir, "site.json"), `{"owner": {"name": "Jane"}}`)
+	writeFile(t, filepath.Join(dataDir, "projects.toml"), "[oojsite]\nlang = \"go\"\n")
+	writeFile(t, filepath.Join(dataDir, "team.csv"), "name,role\nJane,Editor\nSam,Writer\n")
+	writeFile(t, filepath.Join(dataDir, "notes.txt"), "ignored")
+
+	data, err := LoadData(dataDir)
+	if err != nil {
+		t.Fatalf("LoadData: %v", err)
+	}
+
+	nav := data["nav"].(map[string]interface{})["main"].([]interface{})
+	if title := nav[1].(map[string]interface{})["title"]; title != "Blog" {
+		t.Fatalf("expected second nav entry to be Blog, got %v", title)
+	}
+
+	owner := data["site"].(map[string]interface{})["owner"].(map[string]interface{})
+	if owner["name"] != "Jane" {
+		t.Fatalf("expected owner from JSON, got %v", owner["name"])
+	}
+
+	project := data["projects"].(map[string]interface{})["oojsite"].(map[string]interface{})
+	if project["lang"] != "go" {
+		t.Fatalf("expected project from TOML, got %v", project["lang"])
+	}
+
+	team := data["team"].([]map[string]string)
+	if len(team) != 2 || team[1]["role"] != "Writer" {
+		t.Fatalf("unexpected CSV records: %v", team)
+	}
+
+	if _, ok := data["notes"]; ok {
+		t.Fatal("expected non-data files to be skipped")
+	}
+}
+
+func TestLoadDataAllowsMissingDir(t *testing.T) {
+	data, err := LoadData(filepath.Join(t.TempDir(), "data"))
+	if err != nil || len(data) != 0 {
+		t.Fatalf("expected no data for a missing dir, got %v, %v", data, err)
+	}
+}
+
+func TestLoadDataRejectsConflictingKeys(t *testing.T) {
+	dataDir := t.TempDir()
+	writeFile(t, filepath.Join(dataDir, "nav.yaml"), "- home\n")
+	writeFile(t, filepath.Join(dataDir, "nav", "main.yaml"), "- blog\n")
+
+	if _, err := LoadData(dataDir); err == nil {
+		t.Fatal("expected conflicting data keys to be rejected")
+	}
+}
@@ -0,0 +1,24 @@
+package content
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"oojsite/internal/model"
+	"oojsite/internal/parse"
+)
+
+// postDate parses a date field of the post, warning when it is set but can't
+// be read.
+func postDate(post *model.Post, field, dateFormat string) time.Time {
+	value, ok := post.Frontmatter[field]
+	if !ok || value == nil || value == "" {
+		return time.Time{}
+	}
+	t, ok := parse.Date(value, dateFormat)
+	if !ok {
+		log.Printf("warning: %s: unrecognised %s %q, ignoring it", post.SourcePath, field, fmt.Sprint(value))
+	}
+	return t
+}
@@ -0,0 +1,231 @@
+package content
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/kaleocheng/goldmark/ast"
+	"github.com/kaleocheng/goldmark/renderer"
+	"github.com/kaleocheng/goldmark/util"
+
+	"oojsite/internal/model"
+)
+
+// LookupStyle returns the named chroma style, or an error listing the
+// available ones.
+func LookupStyle(name string) (*chroma.Style, error) {
+	if name == "" {
+		name = model.DefaultHighlightStyle
+	}
+	style, ok := styles.Registry[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown highlight style %q (available: %s)", name, strings.Join(styles.Names(), ", "))
+	}
+	return style, nil
+}
+
+// HighlightCSS returns the stylesheet for highlighted code rendered with
+// Classes set.
+func HighlightCSS(opts model.HighlightOptions) (string, error) {
+	style, err := LookupStyle(opts.Style)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := chromahtml.New(chromahtml.WithClasses(true)).WriteCSS(&b, style); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+type highlighter struct {
+	opts  model.HighlightOptions
+	style *chroma.Style
+}
+
+func (h *highlighter) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, h.renderFencedCodeBlock)
+}
+
+func (h *highlighter) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.FencedCodeBlock)
+
+	var code strings.Builder
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		code.Write(line.Value(source))
+	}
+
+	language := string(n.Language(source))
+	if language == "" {
+		_, _ = w.WriteString("<pre><code>" + html.EscapeString(code.String()) + "</code></pre>\n")
+		return ast.WalkSkipChildren, nil
+	}
+
+	var info string
+	if n.Info != nil {
+		info = string(n.Info.Segment.Value(source))
+	}
+	attrs, err := parseFenceAttributes(strings.TrimSpace(strings.TrimPrefix(info, language)))
+	if err != nil {
+		return ast.WalkStop, fmt.Errorf("code block %q: %w", info, err)
+	}
+
+	lexer := lexers.Get(language)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code.String())
+	if err != nil {
+		return ast.WalkStop, err
+	}
+
+	formatter := chromahtml.New(h.formatterOptions(attrs)...)
+	if err := formatter.Format(w, h.style, iterator); err != nil {
+		return ast.WalkStop, err
+	}
+	_ = w.WriteByte('\n')
+	return ast.WalkSkipChildren, nil
+}
+
+func (h *highlighter) formatterOptions(attrs fenceAttributes) []chromahtml.Option {
+	options := []chromahtml.Option{chromahtml.WithClasses(h.opts.Classes)}
+
+	lineNumbers := h.opts.LineNumbers
+	if attrs.lineNumbers != nil {
+		lineNumbers = *attrs.lineNumbers
+	}
+	if lineNumbers {
+		options = append(options, chromahtml.WithLineNumbers(true), chromahtml.LineNumbersInTable(attrs.table))
+	}
+	if attrs.start > 0 {
+		options = append(options, chromahtml.BaseLineNumber(attrs.start))
+	}
+	if len(attrs.highlight) > 0 {
+		// hl_lines counts from the first line of the block, not linenostart
+		ranges := make([][2]int, len(attrs.highlight))
+		for i, r := range attrs.highlight {
+			offset := max(attrs.start, 1) - 1
+			ranges[i] = [2]int{r[0] + offset, r[1] + offset}
+		}
+		options = append(options, chromahtml.HighlightLines(ranges))
+	}
+	return options
+}
+
+type fenceAttributes struct {
+	lineNumbers *bool
+	table       bool
+	start       int
+	highlight   [][2]int
+}
+
+// parseFenceAttributes reads the {key=value ...} block that may end the info
+// text of a code fence, e.g. {linenos=table hl_lines="2 4-6"}. Entries can be
+// separated by spaces or commas, and hl_lines also accepts a list such as
+// [2, "4-6"]. Other info text and unknown keys are meant for other tools and
+// are ignored; only bad values for the keys handled here are errors.
+func parseFenceAttributes(s string) (fenceAttributes, error) {
+	var attrs fenceAttributes
+	open := strings.Index(s, "{")
+	if open < 0 || !strings.HasSuffix(s, "}") {
+		return attrs, nil
+	}
+
+	for _, entry := range splitAttributes(s[open+1 : len(s)-1]) {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		switch strings.TrimSpace(key) {
+		case "linenos":
+			enabled := value != "false"
+			attrs.lineNumbers = &enabled
+			attrs.table = value == "table"
+		case "linenostart":
+			start, err := strconv.Atoi(value)
+			if err != nil || start < 1 {
+				return attrs, fmt.Errorf("invalid linenostart %q", value)
+			}
+			attrs.start = start
+		case "hl_lines":
+			ranges, err := parseLineRanges(value)
+			if err != nil {
+				return attrs, err
+			}
+			attrs.highlight = ranges
+		}
+	}
+	return attrs, nil
+}
+
+// splitAttributes splits on spaces and commas outside quotes and brackets.
+func splitAttributes(s string) []string {
+	var entries []string
+	var current strings.Builder
+	var quote rune
+	depth := 0
+
+	flush := func() {
+		if entry := strings.TrimSpace(current.String()); entry != "" {
+			entries = append(entries, entry)
+		}
+		current.Reset()
+	}
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case (r == ' ' || r == ',') && depth == 0:
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+	return entries
+}
+
+// parseLineRanges reads line numbers and ranges such as "2 4-6" or
+// [2, "4-6"].
+func parseLineRanges(s string) ([][2]int, error) {
+	s = strings.Trim(s, "[]")
+	var ranges [][2]int
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' }) {
+		field = strings.Trim(field, `"'`)
+		from, to, isRange := strings.Cut(field, "-")
+		start, err := strconv.Atoi(from)
+		if err != nil || start < 1 {
+			return nil, fmt.Errorf("invalid hl_lines entry %q", field)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil || end < start {
+				return nil, fmt.Errorf("invalid hl_lines entry %q", field)
+			}
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges, nil
+}
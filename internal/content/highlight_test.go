@@ -0,0 +1,82 @@
+package content
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"oojsite/internal/model"
+)
+
+func convert(t *testing.T, opts MarkdownOptions, source string) string {
+	t.Helper()
+	md, err := NewMarkdown(opts)
+	if err != nil {
+		t.Fatalf("NewMarkdown: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(source), &buf); err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	return buf.String()
+}
+
+func TestHighlightInlineStyles(t *testing.T) {
+	out := convert(t, MarkdownOptions{Highlight: model.HighlightOptions{Enabled: true}}, "```go\nfunc main() {}\n```\n")
+	if !strings.Contains(out, `<pre style="`) || !strings.Contains(out, `>func</span>`) {
+		t.Fatalf("expected inline-styled highlighting, got:\n%s", out)
+	}
+}
+
+func TestHighlightClassesAndFenceAttributes(t *testing.T) {
+	opts := MarkdownOptions{Highlight: model.HighlightOptions{Enabled: true, Classes: true}}
+	out := convert(t, opts, "```bash {linenos=true, hl_lines=[2]}\necho one\necho two\n```\n")
+	if !strings.Contains(out, `class="chroma"`) || strings.Contains(out, `style="`) {
+		t.Fatalf("expected class-based highlighting, got:\n%s", out)
+	}
+	if !strings.Contains(out, `<span class="line hl"><span class="ln">2</span>`) {
+		t.Fatalf("expected line 2 numbered and highlighted, got:\n%s", out)
+	}
+}
+
+func TestHighlightLeavesUnlabelledBlocks(t *testing.T) {
+	out := convert(t, MarkdownOptions{Highlight: model.HighlightOptions{Enabled: true}}, "```\n<b>plain</b>\n```\n")
+	if out != "<pre><code>&lt;b&gt;plain&lt;/b&gt;\n</code></pre>\n" {
+		t.Fatalf("unexpected output for a block without a language:\n%s", out)
+	}
+}
+
+func TestParseFenceAttributes(t *testing.T) {
+	attrs, err := parseFenceAttributes(`{linenos=table linenostart=10 hl_lines="1 3-4"}`)
+	if err != nil {
+		t.Fatalf("parseFenceAttributes: %v", err)
+	}
+	if attrs.lineNumbers == nil || !*attrs.lineNumbers || !attrs.table || attrs.start != 10 {
+		t.Fatalf("unexpected attributes: %+v", attrs)
+	}
+	if len(attrs.highlight) != 2 || attrs.highlight[1] != [2]int{3, 4} {
+		t.Fatalf("unexpected hl_lines: %v", attrs.highlight)
+	}
+
+	for _, info := range []string{`title="main.go"`, `{colour=red}`, `title="main.go" {linenos=false}`} {
+		if _, err := parseFenceAttributes(info); err != nil {
+			t.Fatalf("parseFenceAttributes(%q): unexpected error %v", info, err)
+		}
+	}
+	if _, err := parseFenceAttributes(`{linenostart=zero}`); err == nil {
+		t.Fatal("expected a bad linenostart to be rejected")
+	}
+}
+
+func TestHighlightIgnoresOtherInfoText(t *testing.T) {
+	out := convert(t, MarkdownOptions{Highlight: model.HighlightOptions{Enabled: true, Classes: true}}, "```go title=\"main.go\"\npackage main\n```\n")
+	if !strings.Contains(out, `<span class="kn">package</span>`) {
+		t.Fatalf("expected the block to be highlighted:\n%s", out)
+	}
+}
+
+func TestLookupStyleRejectsUnknown(t *testing.T) {
+	if _, err := LookupStyle("no-such-style"); err == nil {
+		t.Fatal("expected an unknown style to be rejected")
+	}
+}
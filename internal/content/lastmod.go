@@ -0,0 +1,190 @@
+package content
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"oojsite/internal/model"
+	"oojsite/internal/parse"
+)
+
+var changeFreqs = map[string]bool{
+	"always": true, "hourly": true, "daily": true, "weekly": true,
+	"monthly": true, "yearly": true, "never": true,
+}
+
+// modTimes reports when source files last changed, either from their mtime
+// or, when useGit is set, from the last commit touching them.
+type modTimes struct {
+	git map[string]time.Time
+}
+
+func newModTimes(useGit bool, dir string) (*modTimes, error) {
+	if !useGit {
+		return &modTimes{}, nil
+	}
+	git, err := gitCommitTimes(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git history: %w", err)
+	}
+	return &modTimes{git: git}, nil
+}
+
+func (m *modTimes) lookup(path string) time.Time {
+	if m.git != nil {
+		// git reports paths under the resolved repository root
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			if abs, err := filepath.Abs(real); err == nil {
+				if t, ok := m.git[abs]; ok {
+					return t
+				}
+			}
+		}
+	}
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// gitCommitTimes maps every file under dir to the time of the last commit
+// that touched it. Uncommitted files are left out.
+func gitCommitTimes(dir string) (map[string]time.Time, error) {
+	git := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		return cmd.Output()
+	}
+
+	top, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(string(top))
+
+	// -z keeps paths unquoted, so names with non-ASCII characters match.
+	// Fields end in NUL and each commit's first path follows a newline.
+	out, err := git("log", "-z", "--format=%x01%cI", "--name-only", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	times := make(map[string]time.Time)
+	var current time.Time
+	for _, field := range strings.Split(string(out), "\x00") {
+		field = strings.TrimPrefix(field, "\n")
+		if stamp, ok := strings.CutPrefix(field, "\x01"); ok {
+			current, _ = time.Parse(time.RFC3339, stamp)
+			continue
+		}
+		if field == "" {
+			continue
+		}
+		// Newest commits come first, so keep the first time seen
+		path := filepath.Join(root, filepath.FromSlash(field))
+		if _, seen := times[path]; !seen {
+			times[path] = current
+		}
+	}
+	return times, nil
+}
+
+// postLastMod prefers the post's lastmod and date fields over the source file.
+func postLastMod(post *model.Post, times *modTimes, dateFormat string) time.Time {
+	if lastmod, ok := parse.Date(post.Frontmatter["lastmod"], dateFormat); ok {
+		return lastmod
+	}
+	if !post.Date.IsZero() {
+		return post.Date
+	}
+	return times.lookup(post.SourcePath)
+}
+
+// SitemapEntries collects what is known about the generated pages for the
+// sitemap, keyed by their output path relative to outDir.
+func SitemapEntries(global model.GlobalData, pageDir string, useGit bool) (map[string]model.SitemapEntry, error) {
+	entries := make(map[string]model.SitemapEntry)
+
+	for _, post := range global.Posts {
+		entry, err := postSitemapEntry(post)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", post.SourcePath, err)
+		}
+		entries[post.OutputRel] = entry
+	}
+
+	for _, taxonomy := range global.Taxonomies {
+		var newest time.Time
+		for _, term := range taxonomy.Terms {
+			latest := latestLastMod(term.Posts)
+			entries[outputFile(term.URL)] = model.SitemapEntry{LastMod: latest}
+			if latest.After(newest) {
+				newest = latest
+			}
+		}
+		entries[outputFile(taxonomy.URL)] = model.SitemapEntry{LastMod: newest}
+	}
+
+	times, err := newModTimes(useGit, pageDir)
+	if err != nil {
+		return nil, err
+	}
+	err = filepath.Walk(pageDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".html") {
+			return err
+		}
+		rel, err := filepath.Rel(pageDir, path)
+		if err != nil {
+			return err
+		}
+		entries[filepath.ToSlash(rel)] = model.SitemapEntry{LastMod: times.lookup(path)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func latestLastMod(posts []model.Post) time.Time {
+	var latest time.Time
+	for _, post := range posts {
+		if post.LastMod.After(latest) {
+			latest = post.LastMod
+		}
+	}
+	return latest
+}
+
+// postSitemapEntry applies the post's sitemap frontmatter, e.g.
+// sitemap: {priority: 0.8, changefreq: weekly, exclude: true}.
+func postSitemapEntry(post model.Post) (model.SitemapEntry, error) {
+	entry := model.SitemapEntry{LastMod: post.LastMod}
+
+	settings, _ := post.Frontmatter["sitemap"].(map[string]interface{})
+	entry.Exclude = isTrue(settings["exclude"])
+
+	if value, ok := settings["changefreq"]; ok {
+		freq := strings.ToLower(fmt.Sprint(value))
+		if !changeFreqs[freq] {
+			return entry, fmt.Errorf("invalid sitemap changefreq %q", freq)
+		}
+		entry.ChangeFreq = freq
+	}
+
+	if value, ok := settings["priority"]; ok {
+		priority, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		if err != nil || priority < 0 || priority > 1 {
+			return entry, fmt.Errorf("invalid sitemap priority %v (expected 0.0 to 1.0)", value)
+		}
+		entry.Priority = strconv.FormatFloat(priority, 'f', -1, 64)
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,109 @@
+package content
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"oojsite/internal/model"
+)
+
+func TestLoadPostsLastMod(t *testing.T) {
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(postsDir, "edited.md"), "---\ndate: 2024-01-01\nlastmod: 2024-02-01\n---\nbody")
+	writeFile(t, filepath.Join(postsDir, "dated.md"), "---\ndate: 2024-01-01\n---\nbody")
+	writeFile(t, filepath.Join(postsDir, "undated.md"), "body")
+	mtime := time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(postsDir, "undated.md"), mtime, mtime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	posts, err := LoadPosts(postsDir, LoadOptions{BuildTime: time.Now()})
+	if err != nil {
+		t.Fatalf("LoadPosts: %v", err)
+	}
+
+	expected := map[string]time.Time{
+		"dated.md":   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"edited.md":  time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		"undated.md": mtime,
+	}
+	for _, post := range posts {
+		want := expected[filepath.Base(post.SourcePath)]
+		if !post.LastMod.Equal(want) {
+			t.Fatalf("%s: expected lastmod %v, got %v", post.SourcePath, want, post.LastMod)
+		}
+	}
+}
+
+func TestLoadPostsGitLastMod(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repo := t.TempDir()
+	writeFile(t, filepath.Join(repo, "posts", "committed.md"), "body")
+	writeFile(t, filepath.Join(repo, "posts", "café.md"), "body")
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_COMMITTER_DATE=2022-04-05T06:07:08Z")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q")
+	run("add", ".")
+	run("commit", "-q", "-m", "add post")
+
+	posts, err := LoadPosts(filepath.Join(repo, "posts"), LoadOptions{BuildTime: time.Now(), GitLastMod: true})
+	if err != nil {
+		t.Fatalf("LoadPosts: %v", err)
+	}
+	want := time.Date(2022, 4, 5, 6, 7, 8, 0, time.UTC)
+	for _, post := range posts {
+		if !post.LastMod.Equal(want) {
+			t.Fatalf("%s: expected commit time %v, got %v", post.SourcePath, want, post.LastMod)
+		}
+	}
+}
+
+func TestSitemapEntriesFrontmatterOverrides(t *testing.T) {
+	pageDir := t.TempDir()
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(pageDir, "index.html"), "<p>home</p>")
+	writeFile(t, filepath.Join(postsDir, "hidden.md"), "---\nsitemap:\n  exclude: true\n---\nbody")
+	writeFile(t, filepath.Join(postsDir, "tuned.md"), "---\ndate: 2024-01-01\nsitemap:\n  priority: 0.8\n  changefreq: Weekly\n---\nbody")
+
+	posts, err := LoadPosts(postsDir, LoadOptions{BuildTime: time.Now()})
+	if err != nil {
+		t.Fatalf("LoadPosts: %v", err)
+	}
+
+	entries, err := SitemapEntries(model.GlobalData{Posts: posts}, pageDir, false)
+	if err != nil {
+		t.Fatalf("SitemapEntries: %v", err)
+	}
+	if !entries["posts/hidden/index.html"].Exclude {
+		t.Fatal("expected hidden post to be excluded")
+	}
+	tuned := entries["posts/tuned/index.html"]
+	if tuned.Priority != "0.8" || tuned.ChangeFreq != "weekly" || tuned.LastMod.Format("2006-01-02") != "2024-01-01" {
+		t.Fatalf("unexpected entry for tuned post: %+v", tuned)
+	}
+	if entries["index.html"].LastMod.IsZero() {
+		t.Fatal("expected pages to be dated by their source file")
+	}
+}
+
+func TestSitemapEntriesRejectsBadPriority(t *testing.T) {
+	post := model.Post{Frontmatter: map[string]interface{}{"sitemap": map[string]interface{}{"priority": 2}}}
+	if _, err := SitemapEntries(model.GlobalData{Posts: []model.Post{post}}, t.TempDir(), false); err == nil {
+		t.Fatal("expected a priority above 1.0 to be rejected")
+	}
+}
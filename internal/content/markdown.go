@@ -0,0 +1,104 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/kaleocheng/goldmark"
+	"github.com/kaleocheng/goldmark/extension"
+	"github.com/kaleocheng/goldmark/parser"
+	"github.com/kaleocheng/goldmark/renderer"
+	"github.com/kaleocheng/goldmark/text"
+	"github.com/kaleocheng/goldmark/util"
+
+	"oojsite/internal/model"
+)
+
+var markdownExtensions = map[string]goldmark.Extender{
+	"tables":          extension.Table,
+	"strikethrough":   extension.Strikethrough,
+	"autolinks":       extension.Linkify,
+	"tasklists":       extension.TaskList,
+	"footnotes":       extension.Footnote,
+	"definitionlists": extension.DefinitionList,
+}
+
+// MarkdownOptions configures the Markdown pipeline shared by post rendering
+// and snippet extraction.
+type MarkdownOptions struct {
+	Extensions []string
+	Highlight  model.HighlightOptions
+	TOC        model.TOCOptions
+}
+
+// Markdown converts post bodies, also collecting their table of contents.
+type Markdown struct {
+	goldmark.Markdown
+	summary goldmark.Markdown // same pipeline without heading ids
+	opts    MarkdownOptions
+}
+
+// CheckExtension reports whether name is a supported Markdown extension.
+func CheckExtension(name string) error {
+	if _, ok := markdownExtensions[name]; ok {
+		return nil
+	}
+	names := make([]string, 0, len(markdownExtensions))
+	for known := range markdownExtensions {
+		names = append(names, known)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("unknown Markdown extension %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// NewMarkdown builds the Markdown converter for opts.
+func NewMarkdown(opts MarkdownOptions) (*Markdown, error) {
+	var options []goldmark.Option
+	for _, name := range opts.Extensions {
+		if err := CheckExtension(name); err != nil {
+			return nil, err
+		}
+		options = append(options, goldmark.WithExtensions(markdownExtensions[name]))
+	}
+
+	if opts.Highlight.Enabled {
+		style, err := LookupStyle(opts.Highlight.Style)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, goldmark.WithRendererOptions(
+			renderer.WithNodeRenderers(util.Prioritized(&highlighter{opts: opts.Highlight, style: style}, 100)),
+		))
+	}
+
+	// Summaries are shown several to a page, where their heading ids would
+	// clash with each other and with the page's own.
+	summary := goldmark.New(options...)
+	options = append(options, goldmark.WithParserOptions(parser.WithASTTransformers(util.Prioritized(headingIDs{}, 100))))
+	return &Markdown{Markdown: goldmark.New(options...), summary: summary, opts: opts}, nil
+}
+
+// render converts source to HTML and returns its table of contents.
+func (md *Markdown) render(source []byte) (template.HTML, model.TableOfContents, error) {
+	doc := md.Parser().Parse(text.NewReader(source))
+	toc := buildTOC(doc, source, md.opts.TOC)
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, source, doc); err != nil {
+		return "", toc, err
+	}
+	return template.HTML(buf.String()), toc, nil
+}
+
+// renderSummary converts a post summary to HTML, leaving its headings
+// without ids.
+func (md *Markdown) renderSummary(source []byte) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := md.summary.Convert(source, &buf); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
@@ -0,0 +1,47 @@
+package content
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"oojsite/internal/model"
+)
+
+func TestMarkdownExtensionsAreSwitchable(t *testing.T) {
+	source := "| a | b |\n| - | - |\n| 1 | 2 |\n\n~~gone~~ and https://example.com\n\n- [x] done\n\nTerm\n: Definition\n\nNote[^1]\n\n[^1]: Footnote\n"
+
+	all := convert(t, MarkdownOptions{Extensions: model.DefaultMarkdownExtensions}, source)
+	for _, want := range []string{"<table>", "<del>gone</del>", `<a href="https://example.com">`, `<input checked="" disabled="" type="checkbox">`, "<dl>", `class="footnotes"`} {
+		if !strings.Contains(all, want) {
+			t.Fatalf("expected %s with every extension enabled, got:\n%s", want, all)
+		}
+	}
+
+	tablesOnly := convert(t, MarkdownOptions{Extensions: []string{"tables"}}, source)
+	if !strings.Contains(tablesOnly, "<table>") || strings.Contains(tablesOnly, "<del>") {
+		t.Fatalf("expected only tables to be enabled, got:\n%s", tablesOnly)
+	}
+
+	if _, err := NewMarkdown(MarkdownOptions{Extensions: []string{"emoji"}}); err == nil {
+		t.Fatal("expected an unknown extension to be rejected")
+	}
+}
+
+func TestLoadPostsSnippetUsesConfiguredParser(t *testing.T) {
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(postsDir, "table.md"), "| Name | Role |\n| ---- | ---- |\n| Ada | Author |\n")
+
+	md, err := NewMarkdown(MarkdownOptions{Extensions: []string{"tables"}})
+	if err != nil {
+		t.Fatalf("NewMarkdown: %v", err)
+	}
+	posts, err := LoadPosts(postsDir, LoadOptions{BuildTime: time.Now(), Markdown: md})
+	if err != nil {
+		t.Fatalf("LoadPosts: %v", err)
+	}
+	if posts[0].Snippet != "Name Role Ada Author" {
+		t.Fatalf("expected table cells in the snippet, got %q", posts[0].Snippet)
+	}
+}
@@ -0,0 +1,75 @@
+package content
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"oojsite/internal/model"
+)
+
+// pageContext is what pages are executed with. Calling .Paginate from a page
+// records the paginator, which tells RenderPages to emit the remaining pages.
+type pageContext struct {
+	model.PageData
+	page   int
+	urlFor func(page int) string
+}
+
+func (c *pageContext) Paginate(perPage int, posts []model.Post) (*model.Paginator, error) {
+	if c.Paginator != nil {
+		return c.Paginator, nil
+	}
+	if perPage < 1 {
+		return nil, fmt.Errorf("paginate: page size must be positive, got %d", perPage)
+	}
+
+	total := (len(posts) + perPage - 1) / perPage
+	if total == 0 {
+		total = 1
+	}
+
+	start := min((c.page-1)*perPage, len(posts))
+	end := min(start+perPage, len(posts))
+
+	pager := &model.Paginator{
+		Items:      posts[start:end],
+		PageNumber: c.page,
+		PerPage:    perPage,
+		TotalPages: total,
+		TotalItems: len(posts),
+		FirstURL:   c.urlFor(1),
+		LastURL:    c.urlFor(total),
+	}
+	if c.page > 1 {
+		pager.PrevURL = c.urlFor(c.page - 1)
+	}
+	if c.page < total {
+		pager.NextURL = c.urlFor(c.page + 1)
+	}
+
+	c.Paginator = pager
+	return pager, nil
+}
+
+// pagePaths returns the output path and URL of page n of the page template at
+// rel. The first page keeps its own location; later pages live under
+// page/<n>/ next to it, so archive.html continues at /archive/page/2/.
+func pagePaths(rel string, n int) (string, string) {
+	rel = path.Clean(strings.ReplaceAll(rel, "\\", "/"))
+	dir, file := path.Split(rel)
+
+	if n == 1 {
+		if file == "index.html" {
+			return rel, "/" + dir
+		}
+		return rel, "/" + rel
+	}
+
+	root := dir
+	if file != "index.html" {
+		root = path.Join(dir, strings.TrimSuffix(file, path.Ext(file))) + "/"
+	}
+	out := path.Join(root, "page", fmt.Sprint(n), "index.html")
+	return out, "/" + path.Dir(out) + "/"
+}
@@ -0,0 +1,78 @@
+package content
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"oojsite/internal/model"
+	"oojsite/internal/templates"
+)
+
+func TestRenderPagesPaginates(t *testing.T) {
+	root := t.TempDir()
+	tmplDir := filepath.Join(root, "templates")
+	componentDir := filepath.Join(root, "components")
+	siteDir := filepath.Join(root, "site")
+	outDir := filepath.Join(root, "out")
+	for _, dir := range []string{tmplDir, componentDir, siteDir, outDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("mkdir %s: %v", dir, err)
+		}
+	}
+
+	writeFile(t, filepath.Join(siteDir, "archive.html"), `{{ with .Paginate 2 (sortBy "n" .Global.Posts) }}{{ .PageNumber }}/{{ .TotalPages }}:{{ range .Items }}{{ get .Frontmatter "n" }},{{ end }}prev={{ .PrevURL }};next={{ .NextURL }}{{ end }}`)
+	writeFile(t, filepath.Join(siteDir, "index.html"), `{{ len (.Paginate 10 .Global.Posts).Items }}`)
+
+	tmpls, err := templates.Load(tmplDir, componentDir, siteDir)
+	if err != nil {
+		t.Fatalf("templates.Load: %v", err)
+	}
+
+	var posts []model.Post
+	for i := 1; i <= 5; i++ {
+		posts = append(posts, model.Post{Frontmatter: map[string]interface{}{"n": i}})
+	}
+
+	if err := RenderPages(siteDir, outDir, model.GlobalData{Posts: posts}, model.Site{}, tmpls); err != nil {
+		t.Fatalf("RenderPages: %v", err)
+	}
+
+	expected := map[string]string{
+		"archive.html":              "1/3:1,2,prev=;next=/archive/page/2/",
+		"archive/page/2/index.html": "2/3:3,4,prev=/archive.html;next=/archive/page/3/",
+		"archive/page/3/index.html": "3/3:5,prev=/archive/page/2/;next=",
+		"index.html":                "5",
+	}
+	for rel, want := range expected {
+		if got := readFile(t, filepath.Join(outDir, rel)); got != want {
+			t.Fatalf("unexpected output for %s: %q", rel, got)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(outDir, "page")); !os.IsNotExist(err) {
+		t.Fatal("expected single-page paginator not to emit extra pages")
+	}
+}
+
+func TestPagePaths(t *testing.T) {
+	cases := []struct {
+		rel string
+		n   int
+		out string
+		url string
+	}{
+		{"index.html", 1, "index.html", "/"},
+		{"index.html", 2, "page/2/index.html", "/page/2/"},
+		{"blog/index.html", 3, "blog/page/3/index.html", "/blog/page/3/"},
+		{"blog/archive.html", 1, "blog/archive.html", "/blog/archive.html"},
+		{"blog/archive.html", 2, "blog/archive/page/2/index.html", "/blog/archive/page/2/"},
+	}
+
+	for _, c := range cases {
+		out, url := pagePaths(c.rel, c.n)
+		if out != c.out || url != c.url {
+			t.Fatalf("pagePaths(%q, %d) = %q, %q; want %q, %q", c.rel, c.n, out, url, c.out, c.url)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package content
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"oojsite/internal/model"
+)
+
+var permalinkToken = regexp.MustCompile(`:(year|month|day|section|path|slug|filename)`)
+
+func setPermalink(post *model.Post, postDir, pattern string) error {
+	rel, err := filepath.Rel(postDir, post.SourcePath)
+	if err != nil {
+		return err
+	}
+
+	rel = filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+	post.Section, _, _ = strings.Cut(path.Dir(rel), "/")
+	if post.Section == "." {
+		post.Section = ""
+	}
+
+	url, err := permalink(post, rel, pattern)
+	if err != nil {
+		return fmt.Errorf("%s: %w", post.SourcePath, err)
+	}
+	post.Filepath = url
+	post.OutputRel = outputFile(url)
+	return nil
+}
+
+// permalink resolves a post's URL from its "url" frontmatter or the pattern.
+// rel is the post's path relative to the post dir, without extension.
+func permalink(post *model.Post, rel, pattern string) (string, error) {
+	if url, ok := post.Frontmatter["url"].(string); ok && strings.TrimSpace(url) != "" {
+		return cleanURL(url), nil
+	}
+
+	_, filename := path.Split(rel)
+
+	slug := filename
+	if custom, ok := post.Frontmatter["slug"].(string); ok && strings.TrimSpace(custom) != "" {
+		slug = strings.Trim(strings.TrimSpace(custom), "/")
+	}
+
+	var missing []string
+	url := permalinkToken.ReplaceAllStringFunc(pattern, func(token string) string {
+		switch token {
+		case ":year", ":month", ":day":
+			if post.Date.IsZero() {
+				missing = append(missing, token)
+				return ""
+			}
+			switch token {
+			case ":year":
+				return post.Date.Format("2006")
+			case ":month":
+				return post.Date.Format("01")
+			}
+			return post.Date.Format("02")
+		case ":section":
+			return post.Section
+		case ":path":
+			return rel
+		case ":slug":
+			return slug
+		}
+		return filename
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("permalink %s uses %s but the post has no date", pattern, strings.Join(missing, ", "))
+	}
+
+	return cleanURL(url), nil
+}
+
+// cleanURL collapses the empty segments left by unset tokens and keeps a
+// trailing slash for directory-style URLs.
+func cleanURL(url string) string {
+	trailing := strings.HasSuffix(url, "/") || path.Ext(url) == ""
+	url = path.Clean("/" + url)
+	if trailing && url != "/" {
+		url += "/"
+	}
+	return url
+}
+
+// outputFile maps a URL onto the file that serves it, relative to outDir.
+func outputFile(url string) string {
+	rel := strings.TrimPrefix(url, "/")
+	if rel == "" || strings.HasSuffix(rel, "/") {
+		return rel + "index.html"
+	}
+	return rel
+}
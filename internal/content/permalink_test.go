@@ -0,0 +1,64 @@
+package content
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadPostsAppliesPermalinks(t *testing.T) {
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(postsDir, "blog", "2024-hello.md"), "---\ndate: 2024-03-05\nslug: hello\n---\nbody")
+	writeFile(t, filepath.Join(postsDir, "about.md"), "---\nslug: me\n---\nbody")
+	writeFile(t, filepath.Join(postsDir, "legacy.md"), "---\nurl: /old/legacy.html\n---\nbody")
+
+	posts, err := LoadPosts(postsDir, LoadOptions{Permalink: "/:section/:slug/", BuildTime: time.Now()})
+	if err != nil {
+		t.Fatalf("LoadPosts: %v", err)
+	}
+
+	expected := map[string][2]string{
+		"about.md":           {"/me/", "me/index.html"},
+		"blog/2024-hello.md": {"/blog/hello/", "blog/hello/index.html"},
+		"legacy.md":          {"/old/legacy.html", "old/legacy.html"},
+	}
+	for _, post := range posts {
+		rel, _ := filepath.Rel(postsDir, post.SourcePath)
+		want := expected[filepath.ToSlash(rel)]
+		if post.Filepath != want[0] || post.OutputRel != want[1] {
+			t.Fatalf("%s: got %s -> %s, want %s -> %s", rel, post.Filepath, post.OutputRel, want[0], want[1])
+		}
+	}
+}
+
+func TestLoadPostsDefaultPermalinkKeepsFileLayout(t *testing.T) {
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(postsDir, "guides", "setup.md"), "---\nslug: ignored\n---\nbody")
+
+	posts, err := LoadPosts(postsDir, LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadPosts: %v", err)
+	}
+	if posts[0].Filepath != "/posts/guides/setup/" || posts[0].OutputRel != "posts/guides/setup/index.html" {
+		t.Fatalf("unexpected default permalink: %s -> %s", posts[0].Filepath, posts[0].OutputRel)
+	}
+}
+
+func TestLoadPostsRejectsPermalinkConflicts(t *testing.T) {
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(postsDir, "a.md"), "---\nslug: same\n---\nbody")
+	writeFile(t, filepath.Join(postsDir, "b.md"), "---\nslug: same\n---\nbody")
+
+	if _, err := LoadPosts(postsDir, LoadOptions{Permalink: "/:slug/"}); err == nil {
+		t.Fatal("expected two posts with the same URL to be rejected")
+	}
+}
+
+func TestLoadPostsRequiresDateForDatePermalinks(t *testing.T) {
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(postsDir, "undated.md"), "body")
+
+	if _, err := LoadPosts(postsDir, LoadOptions{Permalink: "/:year/:slug/"}); err == nil {
+		t.Fatal("expected date tokens without a date to be rejected")
+	}
+}
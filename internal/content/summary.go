@@ -0,0 +1,89 @@
+package content
+
+import (
+	"bytes"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/kaleocheng/goldmark/ast"
+	"github.com/kaleocheng/goldmark/text"
+
+	"oojsite/internal/model"
+)
+
+var moreMarker = regexp.MustCompile(`^<!--\s*more\s*-->$`)
+
+// summarize sets the post's word count, plain-text Snippet and HTML Summary.
+// The summary comes from the "summary" frontmatter field, else everything
+// before a <!--more--> marker, else the first snippetWords words.
+func summarize(post *model.Post, md *Markdown, snippetWords int) error {
+	var summarySource []byte
+	if start, stop, ok := findMore(md, post.Raw); ok {
+		summarySource = post.Raw[:start]
+		post.Raw = append(append([]byte(nil), post.Raw[:start]...), post.Raw[stop:]...)
+	}
+	if custom, ok := post.Frontmatter["summary"].(string); ok && strings.TrimSpace(custom) != "" {
+		summarySource = []byte(custom)
+	}
+
+	words := strings.Fields(extractText(md, post.Raw))
+	post.WordCount = len(words)
+
+	if summarySource == nil {
+		post.Snippet = makeSnippet(words, snippetWords)
+		if post.Snippet != "" {
+			post.Summary = template.HTML("<p>" + template.HTMLEscapeString(post.Snippet) + "</p>")
+		}
+		return nil
+	}
+
+	summary, err := md.renderSummary(summarySource)
+	if err != nil {
+		return err
+	}
+	post.Summary = summary
+	post.Snippet = strings.Join(strings.Fields(extractText(md, summarySource)), " ")
+	return nil
+}
+
+// findMore locates a <!--more--> marker written as an HTML block of its own
+// at the top level of the document, so markers quoted in code are left alone.
+func findMore(md *Markdown, raw []byte) (start, stop int, ok bool) {
+	doc := md.Parser().Parse(text.NewReader(raw))
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		block, isHTML := n.(*ast.HTMLBlock)
+		if !isHTML || block.Lines().Len() == 0 {
+			continue
+		}
+		start = block.Lines().At(0).Start
+		stop = block.Lines().At(block.Lines().Len() - 1).Stop
+		if block.HasClosure() {
+			stop = block.ClosureLine.Stop
+		}
+		if moreMarker.Match(bytes.TrimSpace(raw[start:stop])) {
+			return start, stop, true
+		}
+	}
+	return 0, 0, false
+}
+
+// makeSnippet keeps at most wordCount words, preferring to stop at the end of
+// a sentence as long as that keeps at least half of them.
+func makeSnippet(words []string, wordCount int) string {
+	if len(words) <= wordCount {
+		return strings.Join(words, " ")
+	}
+
+	for i := wordCount - 1; i >= wordCount/2; i-- {
+		if endsSentence(words[i]) {
+			return strings.Join(words[:i+1], " ")
+		}
+	}
+	return strings.Join(words[:wordCount], " ") + "..."
+}
+
+func endsSentence(word string) bool {
+	word = strings.TrimRight(word, `"')]”’`)
+	return strings.HasSuffix(word, ".") || strings.HasSuffix(word, "!") || strings.HasSuffix(word, "?")
+}
@@ -0,0 +1,114 @@
+package content
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPostsSummaries(t *testing.T) {
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(postsDir, "marker.md"), "Intro with **bold** text.\n\n<!--more-->\n\nThe rest of the post.")
+	writeFile(t, filepath.Join(postsDir, "custom.md"), "---\nsummary: A *hand-written* summary.\n---\nBody text.\n\n<!-- more -->\n\nMore.")
+	writeFile(t, filepath.Join(postsDir, "plain.md"), "First sentence here. Second sentence is much longer and keeps going past the limit")
+
+	md, err := NewMarkdown(MarkdownOptions{})
+	if err != nil {
+		t.Fatalf("NewMarkdown: %v", err)
+	}
+	posts, err := LoadPosts(postsDir, LoadOptions{BuildTime: time.Now(), Markdown: md, SnippetWords: 5})
+	if err != nil {
+		t.Fatalf("LoadPosts: %v", err)
+	}
+
+	byName := make(map[string]int)
+	for i, post := range posts {
+		byName[filepath.Base(post.SourcePath)] = i
+	}
+
+	marker := posts[byName["marker.md"]]
+	if marker.Summary != "<p>Intro with <strong>bold</strong> text.</p>\n" || marker.Snippet != "Intro with bold text." {
+		t.Fatalf("unexpected summary from marker: %q / %q", marker.Summary, marker.Snippet)
+	}
+	if strings.Contains(string(marker.Raw), "more") || marker.WordCount != 9 {
+		t.Fatalf("marker should be dropped from the body: %q (%d words)", marker.Raw, marker.WordCount)
+	}
+
+	custom := posts[byName["custom.md"]]
+	if custom.Summary != "<p>A <em>hand-written</em> summary.</p>\n" || custom.Snippet != "A hand-written summary." {
+		t.Fatalf("frontmatter summary should win: %q / %q", custom.Summary, custom.Snippet)
+	}
+
+	plain := posts[byName["plain.md"]]
+	if plain.Snippet != "First sentence here." || plain.Summary != "<p>First sentence here.</p>" {
+		t.Fatalf("expected snippet cut at the sentence end: %q / %q", plain.Snippet, plain.Summary)
+	}
+}
+
+func TestMakeSnippet(t *testing.T) {
+	cases := []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{"Short text.", 5, "Short text."},
+		{"One two three four. Five six seven", 5, "One two three four."},
+		{"Hi. Then a long run without any stop", 5, "Hi. Then a long run..."},
+		{`He said "stop!" and left quietly then`, 4, `He said "stop!"`},
+	}
+	for _, c := range cases {
+		if got := makeSnippet(strings.Fields(c.text), c.limit); got != c.want {
+			t.Fatalf("makeSnippet(%q, %d) = %q, want %q", c.text, c.limit, got, c.want)
+		}
+	}
+}
+
+func TestLoadPostsIgnoresQuotedMoreMarker(t *testing.T) {
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(postsDir, "quoted.md"), "Put `<!--more-->` on its own line.\n\n```markdown\nIntro.\n\n<!--more-->\n\nRest.\n```\n\nThe end.")
+
+	md, err := NewMarkdown(MarkdownOptions{})
+	if err != nil {
+		t.Fatalf("NewMarkdown: %v", err)
+	}
+	posts, err := LoadPosts(postsDir, LoadOptions{BuildTime: time.Now(), Markdown: md})
+	if err != nil {
+		t.Fatalf("LoadPosts: %v", err)
+	}
+
+	post := posts[0]
+	if strings.Count(string(post.Raw), "<!--more-->") != 2 {
+		t.Fatalf("quoted markers should be kept: %q", post.Raw)
+	}
+	if post.Snippet != "Put <!--more--> on its own line. The end." {
+		t.Fatalf("expected an automatic snippet, got %q", post.Snippet)
+	}
+}
+
+func TestLoadPostsSummaryHeadingsHaveNoIDs(t *testing.T) {
+	postsDir := t.TempDir()
+	writeFile(t, filepath.Join(postsDir, "heading.md"), "## Intro\n\nOpening text.\n\n<!--more-->\n\nRest.")
+
+	md, err := NewMarkdown(MarkdownOptions{})
+	if err != nil {
+		t.Fatalf("NewMarkdown: %v", err)
+	}
+	posts, err := LoadPosts(postsDir, LoadOptions{BuildTime: time.Now(), Markdown: md})
+	if err != nil {
+		t.Fatalf("LoadPosts: %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(posts))
+	}
+	if got := string(posts[0].Summary); got != "<h2>Intro</h2>\n<p>Opening text.</p>\n" {
+		t.Fatalf("summary headings should have no id: %q", got)
+	}
+	content, _, err := md.render(posts[0].Raw)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !strings.Contains(string(content), `<h2 id="intro">`) {
+		t.Fatalf("content headings should keep their id: %q", content)
+	}
+}
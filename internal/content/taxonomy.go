@@ -0,0 +1,119 @@
+package content
+
+import (
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"sort"
+
+	"oojsite/internal/model"
+	"oojsite/internal/templates"
+)
+
+// BuildTaxonomies groups posts by the values of each named frontmatter field.
+// Terms that slugify to the same thing ("Go" and "go") are merged.
+func BuildTaxonomies(posts []model.Post, names []string) map[string]model.Taxonomy {
+	taxonomies := make(map[string]model.Taxonomy, len(names))
+
+	for _, name := range names {
+		base := "/" + templates.Slugify(name) + "/"
+		terms := make(map[string]*model.Term)
+
+		for _, post := range posts {
+			for _, value := range termValues(post.Frontmatter[name]) {
+				slug := templates.Slugify(value)
+				if slug == "" {
+					continue
+				}
+				term, ok := terms[slug]
+				if !ok {
+					term = &model.Term{Name: value, Slug: slug, URL: base + slug + "/"}
+					terms[slug] = term
+				}
+				term.Posts = append(term.Posts, post)
+			}
+		}
+
+		taxonomy := model.Taxonomy{Name: name, URL: base}
+		for _, term := range terms {
+			taxonomy.Terms = append(taxonomy.Terms, *term)
+		}
+		sort.Slice(taxonomy.Terms, func(i, j int) bool {
+			return taxonomy.Terms[i].Slug < taxonomy.Terms[j].Slug
+		})
+		taxonomies[name] = taxonomy
+	}
+
+	return taxonomies
+}
+
+// RenderTaxonomies writes a term list page and one page per term for every
+// taxonomy. A taxonomy can override the shared taxonomy.html and term.html
+// templates with <name>/taxonomy.html and <name>/term.html.
+func RenderTaxonomies(global model.GlobalData, site model.Site, outDir string, tmpls *template.Template) error {
+	names := make([]string, 0, len(global.Taxonomies))
+	for name := range global.Taxonomies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		taxonomy := global.Taxonomies[name]
+		dir := filepath.Join(outDir, filepath.FromSlash(taxonomy.URL))
+
+		termTmpl := lookupFirst(tmpls, name+"/term.html", "term.html")
+		if termTmpl == nil {
+			return fmt.Errorf("taxonomy %s: template term.html not found", name)
+		}
+		for _, term := range taxonomy.Terms {
+			data := model.TermData{Taxonomy: taxonomy, Term: term, Global: global, Site: site}
+			outPath := filepath.Join(dir, term.Slug, "index.html")
+			if err := checkUnclaimed(outDir, outPath); err != nil {
+				return fmt.Errorf("taxonomy %s, term %s: %w", name, term.Name, err)
+			}
+			if err := executeTo(outPath, termTmpl, data); err != nil {
+				return fmt.Errorf("taxonomy %s, term %s: %w", name, term.Name, err)
+			}
+		}
+
+		listTmpl := lookupFirst(tmpls, name+"/taxonomy.html", "taxonomy.html")
+		if listTmpl == nil {
+			continue
+		}
+		data := model.TaxonomyData{Taxonomy: taxonomy, Global: global, Site: site}
+		outPath := filepath.Join(dir, "index.html")
+		if err := checkUnclaimed(outDir, outPath); err != nil {
+			return fmt.Errorf("taxonomy %s: %w", name, err)
+		}
+		if err := executeTo(outPath, listTmpl, data); err != nil {
+			return fmt.Errorf("taxonomy %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func lookupFirst(tmpls *template.Template, names ...string) *template.Template {
+	for _, name := range names {
+		if tmpl := tmpls.Lookup(name); tmpl != nil {
+			return tmpl
+		}
+	}
+	return nil
+}
+
+func termValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+		return values
+	}
+	return nil
+}
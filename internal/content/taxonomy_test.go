@@ -0,0 +1,125 @@
+package content
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"oojsite/internal/model"
+	"oojsite/internal/templates"
+)
+
+func TestBuildTaxonomiesGroupsTerms(t *testing.T) {
+	posts := []model.Post{
+		{Frontmatter: map[string]interface{}{"tags": []interface{}{"Go", "Web Dev"}, "category": "notes"}},
+		{Frontmatter: map[string]interface{}{"tags": []interface{}{"go"}}},
+		{Frontmatter: map[string]interface{}{"title": "untagged"}},
+	}
+
+	taxonomies := BuildTaxonomies(posts, []string{"tags", "category"})
+
+	tags := taxonomies["tags"]
+	if tags.URL != "/tags/" || len(tags.Terms) != 2 {
+		t.Fatalf("unexpected tags taxonomy: %+v", tags)
+	}
+	if tags.Terms[0].Slug != "go" || tags.Terms[0].Name != "Go" || len(tags.Terms[0].Posts) != 2 {
+		t.Fatalf("expected Go and go to merge, got %+v", tags.Terms[0])
+	}
+	if tags.Terms[1].URL != "/tags/web-dev/" {
+		t.Fatalf("unexpected term URL: %s", tags.Terms[1].URL)
+	}
+
+	if terms := taxonomies["category"].Terms; len(terms) != 1 || terms[0].Name != "notes" {
+		t.Fatalf("expected scalar field to form a single term, got %+v", terms)
+	}
+}
+
+func TestRenderTaxonomiesWritesListAndTermPages(t *testing.T) {
+	root := t.TempDir()
+	tmplDir := filepath.Join(root, "templates")
+	componentDir := filepath.Join(root, "components")
+	siteDir := filepath.Join(root, "site")
+	outDir := filepath.Join(root, "out")
+	for _, dir := range []string{tmplDir, componentDir, siteDir, outDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("mkdir %s: %v", dir, err)
+		}
+	}
+
+	writeFile(t, filepath.Join(tmplDir, "term.html"), `{{ .Taxonomy.Name }}:{{ .Term.Name }}:{{ len .Term.Posts }}`)
+	writeFile(t, filepath.Join(tmplDir, "tags", "taxonomy.html"), `{{ range .Taxonomy.Terms }}{{ .URL }} {{ end }}`)
+
+	tmpls, err := templates.Load(tmplDir, componentDir, siteDir)
+	if err != nil {
+		t.Fatalf("templates.Load: %v", err)
+	}
+
+	posts := []model.Post{
+		{Frontmatter: map[string]interface{}{"tags": []interface{}{"go", "web"}}},
+		{Frontmatter: map[string]interface{}{"tags": []interface{}{"go"}}},
+	}
+	global := model.GlobalData{Posts: posts, Taxonomies: BuildTaxonomies(posts, []string{"tags"})}
+
+	if err := RenderTaxonomies(global, model.Site{}, outDir, tmpls); err != nil {
+		t.Fatalf("RenderTaxonomies: %v", err)
+	}
+
+	if got := readFile(t, filepath.Join(outDir, "tags", "go", "index.html")); got != "tags:go:2" {
+		t.Fatalf("unexpected term page: %q", got)
+	}
+	if got := readFile(t, filepath.Join(outDir, "tags", "index.html")); got != "/tags/go/ /tags/web/ " {
+		t.Fatalf("unexpected taxonomy page: %q", got)
+	}
+}
+
+func TestRenderTaxonomiesRequiresTermTemplate(t *testing.T) {
+	posts := []model.Post{{Frontmatter: map[string]interface{}{"tags": []interface{}{"go"}}}}
+	global := model.GlobalData{Posts: posts, Taxonomies: BuildTaxonomies(posts, []string{"tags"})}
+
+	if err := RenderTaxonomies(global, model.Site{}, t.TempDir(), template.New("")); err == nil {
+		t.Fatal("expected missing term template to be reported")
+	}
+}
+
+func TestRenderRejectsOverlappingOutputs(t *testing.T) {
+	root := t.TempDir()
+	tmplDir := filepath.Join(root, "templates")
+	componentDir := filepath.Join(root, "components")
+	siteDir := filepath.Join(root, "site")
+	writeFile(t, filepath.Join(tmplDir, "term.html"), `term`)
+	writeFile(t, filepath.Join(tmplDir, "taxonomy.html"), `taxonomy`)
+	writeFile(t, filepath.Join(siteDir, "tags", "index.html"), `page`)
+	if err := os.MkdirAll(componentDir, 0755); err != nil {
+		t.Fatalf("mkdir %s: %v", componentDir, err)
+	}
+
+	tmpls, err := templates.Load(tmplDir, componentDir, siteDir)
+	if err != nil {
+		t.Fatalf("templates.Load: %v", err)
+	}
+	posts := []model.Post{{Frontmatter: map[string]interface{}{"tags": []interface{}{"go"}}}}
+	global := model.GlobalData{Posts: posts, Taxonomies: BuildTaxonomies(posts, []string{"tags"})}
+
+	// A post already rendered at a term's URL
+	outDir := filepath.Join(root, "out-post")
+	writeFile(t, filepath.Join(outDir, "tags", "go", "index.html"), "post")
+	if err := RenderTaxonomies(global, model.Site{}, outDir, tmpls); err == nil {
+		t.Fatal("expected a term page over a post to be rejected")
+	}
+	if got := readFile(t, filepath.Join(outDir, "tags", "go", "index.html")); got != "post" {
+		t.Fatalf("post was overwritten: %q", got)
+	}
+
+	// A page at the taxonomy's URL
+	outDir = filepath.Join(root, "out-page")
+	if err := RenderTaxonomies(global, model.Site{}, outDir, tmpls); err != nil {
+		t.Fatalf("RenderTaxonomies: %v", err)
+	}
+	if err := RenderPages(siteDir, outDir, global, model.Site{}, tmpls); err == nil {
+		t.Fatal("expected a page over the taxonomy page to be rejected")
+	}
+	if got := readFile(t, filepath.Join(outDir, "tags", "index.html")); got != "taxonomy" {
+		t.Fatalf("taxonomy page was overwritten: %q", got)
+	}
+}
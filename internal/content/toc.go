@@ -0,0 +1,131 @@
+package content
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/kaleocheng/goldmark/ast"
+	"github.com/kaleocheng/goldmark/parser"
+	"github.com/kaleocheng/goldmark/text"
+
+	"oojsite/internal/model"
+	"oojsite/internal/templates"
+)
+
+// headingIDs gives every heading an id built with slugify, adding -1, -2, ...
+// to repeated ones. Headings that already carry an id keep it.
+type headingIDs struct{}
+
+func (headingIDs) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	used := make(map[string]bool)
+	var headings []*ast.Heading
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if heading, ok := n.(*ast.Heading); ok && entering {
+			if id, ok := heading.AttributeString("id"); ok {
+				used[fmt.Sprintf("%s", id)] = true
+			} else {
+				headings = append(headings, heading)
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, heading := range headings {
+		base := templates.Slugify(nodeText(heading, source))
+		if base == "" {
+			base = "heading"
+		}
+		id := base
+		for i := 1; used[id]; i++ {
+			id = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[id] = true
+		heading.SetAttributeString("id", []byte(id))
+	}
+}
+
+func nodeText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if t, ok := n.(*ast.Text); ok && entering {
+			b.Write(t.Segment.Value(source))
+			if t.SoftLineBreak() {
+				b.WriteByte(' ')
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(b.String())
+}
+
+// buildTOC collects the document's headings between the configured levels
+// into a tree. A heading deeper than the next level down nests under the
+// closest shallower one.
+func buildTOC(doc ast.Node, source []byte, opts model.TOCOptions) model.TableOfContents {
+	var root []model.TOCEntry
+	var stack []*[]model.TOCEntry
+	var levels []int
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		if heading.Level < opts.MinLevel || heading.Level > opts.MaxLevel {
+			return ast.WalkSkipChildren, nil
+		}
+
+		id, _ := heading.AttributeString("id")
+		entry := model.TOCEntry{
+			Level:  heading.Level,
+			Title:  nodeText(heading, source),
+			Anchor: fmt.Sprintf("%s", id),
+		}
+
+		for len(levels) > 0 && levels[len(levels)-1] >= heading.Level {
+			levels = levels[:len(levels)-1]
+			stack = stack[:len(stack)-1]
+		}
+		siblings := &root
+		if len(stack) > 0 {
+			siblings = stack[len(stack)-1]
+		}
+		*siblings = append(*siblings, entry)
+		added := &(*siblings)[len(*siblings)-1]
+
+		levels = append(levels, heading.Level)
+		stack = append(stack, &added.Children)
+		return ast.WalkSkipChildren, nil
+	})
+
+	return model.TableOfContents{HTML: tocHTML(root), Entries: root}
+}
+
+func tocHTML(entries []model.TOCEntry) template.HTML {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	var list func([]model.TOCEntry)
+	list = func(entries []model.TOCEntry) {
+		b.WriteString("<ul>")
+		for _, entry := range entries {
+			fmt.Fprintf(&b, `<li><a href="#%s">%s</a>`, template.HTMLEscapeString(entry.Anchor), template.HTMLEscapeString(entry.Title))
+			if len(entry.Children) > 0 {
+				list(entry.Children)
+			}
+			b.WriteString("</li>")
+		}
+		b.WriteString("</ul>")
+	}
+
+	b.WriteString(`<nav class="toc">`)
+	list(entries)
+	b.WriteString("</nav>")
+	return template.HTML(b.String())
+}
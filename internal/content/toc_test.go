@@ -0,0 +1,42 @@
+package content
+
+import (
+	"strings"
+	"testing"
+
+	"oojsite/internal/model"
+)
+
+func TestHeadingIDsAreSlugifiedAndUnique(t *testing.T) {
+	out := convert(t, MarkdownOptions{}, "# Getting Started!\n\n## Setup\n\n## Setup\n\n## ✨\n")
+	for _, want := range []string{`<h1 id="getting-started">`, `<h2 id="setup">`, `<h2 id="setup-1">`, `<h2 id="heading">`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %s, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestTableOfContents(t *testing.T) {
+	md, err := NewMarkdown(MarkdownOptions{TOC: model.TOCOptions{MinLevel: 2, MaxLevel: 3}})
+	if err != nil {
+		t.Fatalf("NewMarkdown: %v", err)
+	}
+
+	source := "# Title\n\n## Install `oojsite`\n\n### From source\n\n#### Too deep\n\n## Usage\n\n### <b>Flags</b>\n"
+	_, toc, err := md.render([]byte(source))
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	if len(toc.Entries) != 2 || toc.Entries[0].Title != "Install oojsite" || toc.Entries[0].Anchor != "install-oojsite" {
+		t.Fatalf("unexpected top-level entries: %+v", toc.Entries)
+	}
+	if children := toc.Entries[0].Children; len(children) != 1 || children[0].Level != 3 || children[0].Anchor != "from-source" {
+		t.Fatalf("unexpected nested entries: %+v", children)
+	}
+
+	want := `<nav class="toc"><ul><li><a href="#install-oojsite">Install oojsite</a><ul><li><a href="#from-source">From source</a></li></ul></li><li><a href="#usage">Usage</a><ul><li><a href="#flags">Flags</a></li></ul></li></ul></nav>`
+	if string(toc.HTML) != want {
+		t.Fatalf("unexpected TOC HTML:\n%s", toc.HTML)
+	}
+}
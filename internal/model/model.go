@@ -1,27 +1,124 @@
 package model
 
-import "html/template"
+import (
+	"html/template"
+	"time"
+)
 
 type Post struct {
 	SourcePath  string
 	OutputRel   string
 	Filepath    string
+	Aliases     []string
+	Section     string
 	Frontmatter map[string]interface{}
+	Draft       bool
+	Date        time.Time
+	LastMod     time.Time
 	Snippet     string
+	Summary     template.HTML
+	WordCount   int
+	ReadingTime int
 	Content     template.HTML
 	Raw         []byte
 }
 
+// SitemapEntry holds the sitemap details of one generated page.
+type SitemapEntry struct {
+	LastMod    time.Time
+	ChangeFreq string
+	Priority   string
+	Exclude    bool
+}
+
+type Site struct {
+	BaseURL     string
+	BuildTime   time.Time
+	Environment string
+	Params      map[string]interface{}
+}
+
 type GlobalData struct {
+	Posts      []Post
+	Data       map[string]interface{}
+	Taxonomies map[string]Taxonomy
+}
+
+type Taxonomy struct {
+	Name  string
+	URL   string
+	Terms []Term
+}
+
+type Term struct {
+	Name  string
+	Slug  string
+	URL   string
 	Posts []Post
 }
 
+type TaxonomyData struct {
+	Taxonomy Taxonomy
+	Global   GlobalData
+	Site     Site
+}
+
+type TermData struct {
+	Taxonomy Taxonomy
+	Term     Term
+	Global   GlobalData
+	Site     Site
+}
+
+type RobotsData struct {
+	Site       Site
+	Global     GlobalData
+	SitemapURL string
+	Drafts     []Post
+}
+
 type TemplateData struct {
-	Content     template.HTML
-	Frontmatter map[string]interface{}
-	Global      GlobalData
+	Content         template.HTML
+	TableOfContents TableOfContents
+	Frontmatter     map[string]interface{}
+	Global          GlobalData
+	Site            Site
+}
+
+type TableOfContents struct {
+	HTML    template.HTML
+	Entries []TOCEntry
+}
+
+type TOCEntry struct {
+	Level    int
+	Title    string
+	Anchor   string
+	Children []TOCEntry
 }
 
 type PageData struct {
-	Global GlobalData
+	Global    GlobalData
+	Site      Site
+	Paginator *Paginator
+}
+
+type Paginator struct {
+	Items      []Post
+	PageNumber int
+	PerPage    int
+	TotalPages int
+	TotalItems int
+	FirstURL   string
+	LastURL    string
+	PrevURL    string
+	NextURL    string
+}
+
+func (p *Paginator) HasPrev() bool {
+	return p.PrevURL != ""
+}
+
+func (p *Paginator) HasNext() bool {
+	return p.NextURL != ""
 }
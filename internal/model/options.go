@@ -0,0 +1,33 @@
+package model
+
+// Defaults shared by the config flags and the content loaders.
+const (
+	DefaultPermalink      = "/posts/:path/"
+	DefaultSnippetWords   = 20
+	DefaultWordsPerMinute = 200
+	DefaultHighlightStyle = "github"
+)
+
+// DefaultMarkdownExtensions enables every supported Markdown extension.
+var DefaultMarkdownExtensions = []string{"tables", "strikethrough", "autolinks", "tasklists", "footnotes", "definitionlists"}
+
+// FeedFiles maps each supported feed format onto the file it is written to.
+var FeedFiles = map[string]string{
+	"rss":  "feed.xml",
+	"atom": "atom.xml",
+	"json": "feed.json",
+}
+
+type HighlightOptions struct {
+	Enabled bool
+	Style   string
+	// Classes emits CSS classes instead of inline styles; pair it with the
+	// stylesheet from content.HighlightCSS.
+	Classes     bool
+	LineNumbers bool
+}
+
+type TOCOptions struct {
+	MinLevel int
+	MaxLevel int
+}
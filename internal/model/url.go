@@ -0,0 +1,17 @@
+package model
+
+import "strings"
+
+// AbsURL joins a site-relative path onto the base URL. Without a usable base
+// URL the path is returned unchanged.
+func AbsURL(baseURL, path string) string {
+	if !IsAbsURL(baseURL) {
+		return path
+	}
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// IsAbsURL reports whether baseURL has a scheme, as feeds and sitemaps need.
+func IsAbsURL(baseURL string) bool {
+	return strings.Contains(baseURL, "://")
+}
@@ -0,0 +1,193 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MarkerFile marks a directory as oojsite output and lists the files the last
+// build produced, one relative path per line.
+const MarkerFile = ".oojsite"
+
+// CheckSafe refuses output directories that would take source files or the
+// working directory down with them when cleaned.
+func CheckSafe(dir string, sources []string) error {
+	out, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	if out == filepath.Dir(out) {
+		return fmt.Errorf("output directory %s is the filesystem root", dir)
+	}
+	if home, err := os.UserHomeDir(); err == nil && out == filepath.Clean(home) {
+		return fmt.Errorf("output directory %s is the home directory", dir)
+	}
+	if cwd, err := os.Getwd(); err == nil && Contains(out, cwd) {
+		return fmt.Errorf("output directory %s contains the working directory", dir)
+	}
+
+	for _, source := range sources {
+		src, err := filepath.Abs(source)
+		if err != nil {
+			return err
+		}
+		if Contains(out, src) {
+			return fmt.Errorf("output directory %s contains source directory %s", dir, source)
+		}
+		if Contains(src, out) {
+			return fmt.Errorf("output directory %s is inside source directory %s", dir, source)
+		}
+	}
+
+	return nil
+}
+
+// Contains reports whether path is dir or somewhere beneath it.
+func Contains(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// Clean empties dir. Directories that are not empty must carry the marker
+// file, otherwise they were not produced by oojsite and are left alone. With
+// onlyGenerated set, only the files listed in the marker are removed.
+func Clean(dir string, onlyGenerated bool) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	generated, err := readManifest(dir)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("refusing to clean %s: it is not empty and has no %s marker, so it was not generated by oojsite; remove it yourself or choose another output directory", dir, MarkerFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !onlyGenerated {
+		for _, entry := range entries {
+			if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, rel := range generated {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if !Contains(dir, path) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		removeEmptyParents(dir, filepath.Dir(path))
+	}
+	return os.Remove(filepath.Join(dir, MarkerFile))
+}
+
+// Replace moves a finished build from staging into dir, cleaning dir first,
+// and records what was produced in the marker file.
+func Replace(staging, dir string, onlyGenerated bool) error {
+	files, err := list(staging)
+	if err != nil {
+		return err
+	}
+
+	if err := Clean(dir, onlyGenerated); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(staging, dir); err != nil {
+			return err
+		}
+	} else {
+		for _, rel := range files {
+			dst := filepath.Join(dir, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return err
+			}
+			if err := os.Rename(filepath.Join(staging, filepath.FromSlash(rel)), dst); err != nil {
+				return err
+			}
+		}
+		if err := os.RemoveAll(staging); err != nil {
+			return err
+		}
+	}
+
+	return writeManifest(dir, files)
+}
+
+func list(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel != MarkerFile {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func readManifest(dir string) ([]string, error) {
+	file, err := os.Open(filepath.Join(dir, MarkerFile))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var files []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, scanner.Err()
+}
+
+func writeManifest(dir string, files []string) error {
+	content := strings.Join(files, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	return os.WriteFile(filepath.Join(dir, MarkerFile), []byte(content), 0644)
+}
+
+func removeEmptyParents(root, dir string) {
+	for dir != root && Contains(root, dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,95 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckSafeRejectsDangerousOutputDirs(t *testing.T) {
+	root := t.TempDir()
+	posts := filepath.Join(root, "posts")
+
+	if err := CheckSafe(root, []string{posts}); err == nil {
+		t.Fatal("expected output dir containing a source dir to be rejected")
+	}
+	if err := CheckSafe(filepath.Join(posts, "out"), []string{posts}); err == nil {
+		t.Fatal("expected output dir inside a source dir to be rejected")
+	}
+	if err := CheckSafe(".", nil); err == nil {
+		t.Fatal("expected working directory to be rejected")
+	}
+	if err := CheckSafe(filepath.Join(root, "out"), []string{posts}); err != nil {
+		t.Fatalf("expected sibling output dir to be accepted, got %v", err)
+	}
+}
+
+func TestCleanRefusesUnmarkedDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "important.txt"), "keep me")
+
+	if err := Clean(dir, false); err == nil {
+		t.Fatal("expected unmarked directory to be left alone")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "important.txt")); err != nil {
+		t.Fatalf("expected file to survive refused clean: %v", err)
+	}
+}
+
+func TestReplaceTracksGeneratedFiles(t *testing.T) {
+	root := t.TempDir()
+	out := filepath.Join(root, "out")
+
+	staging := filepath.Join(root, "stage1")
+	writeFile(t, filepath.Join(staging, "index.html"), "v1")
+	writeFile(t, filepath.Join(staging, "posts", "old", "index.html"), "old")
+	if err := Replace(staging, out, true); err != nil {
+		t.Fatalf("first Replace: %v", err)
+	}
+	writeFile(t, filepath.Join(out, "CNAME"), "example.com")
+
+	staging = filepath.Join(root, "stage2")
+	writeFile(t, filepath.Join(staging, "index.html"), "v2")
+	if err := Replace(staging, out, true); err != nil {
+		t.Fatalf("second Replace: %v", err)
+	}
+
+	if got := readFile(t, filepath.Join(out, "index.html")); got != "v2" {
+		t.Fatalf("expected new index, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(out, "posts")); !os.IsNotExist(err) {
+		t.Fatalf("expected stale generated directory to be removed, got %v", err)
+	}
+	if got := readFile(t, filepath.Join(out, "CNAME")); got != "example.com" {
+		t.Fatalf("expected unmanaged file to be kept, got %q", got)
+	}
+	if _, err := os.Stat(staging); !os.IsNotExist(err) {
+		t.Fatalf("expected staging directory to be removed, got %v", err)
+	}
+
+	if err := Clean(out, false); err != nil {
+		t.Fatalf("Clean: %v", err)
+	}
+	if entries, _ := os.ReadDir(out); len(entries) != 0 {
+		t.Fatalf("expected full clean to empty the directory, found %d entries", len(entries))
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
@@ -0,0 +1,103 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// DataFile decodes YAML, JSON, TOML or CSV based on the file extension.
+// Maps always come back as map[string]interface{}; CSV files become a list
+// of records keyed by the header row.
+func DataFile(path string, content []byte) (interface{}, error) {
+	var value interface{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(content, &value); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+	case ".json":
+		if err := json.Unmarshal(content, &value); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+	case ".toml":
+		table := make(map[string]interface{})
+		if _, err := toml.Decode(string(content), &table); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+		value = table
+	case ".csv":
+		records, err := parseCSV(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("unsupported data file %s", path)
+	}
+
+	return Normalize(value), nil
+}
+
+func parseCSV(content []byte) ([]map[string]string, error) {
+	rows, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	records := make([]map[string]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		record := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(row) {
+				record[name] = row[i]
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Normalize converts the map[interface{}]interface{} values produced by
+// yaml.v2 into map[string]interface{} so they behave like TOML and JSON data.
+func Normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[fmt.Sprintf("%v", key)] = Normalize(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = Normalize(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = Normalize(item)
+		}
+		return out
+	case []map[string]interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = Normalize(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
@@ -0,0 +1,41 @@
+// Package parse reads the date and data formats shared by configuration and
+// content.
+package parse
+
+import (
+	"strings"
+	"time"
+)
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// Date understands the date formats used in frontmatter, trying any
+// extra layouts first and falling back to false for anything else. Dates
+// without a zone are taken as UTC.
+func Date(value interface{}, layouts ...string) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		s := strings.TrimSpace(v)
+		for _, layout := range append(layouts, dateLayouts...) {
+			if layout == "" {
+				continue
+			}
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
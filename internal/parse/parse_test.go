@@ -0,0 +1,34 @@
+package parse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateTriesExtraLayoutsFirst(t *testing.T) {
+	got, ok := Date("02/01/2099", "02/01/2006")
+	if !ok || !got.Equal(time.Date(2099, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected date %v (ok=%v)", got, ok)
+	}
+	if _, ok := Date("March 1, 2024", ""); !ok {
+		t.Fatal("expected the built-in layouts to still apply")
+	}
+	if _, ok := Date("sometime soon"); ok {
+		t.Fatal("expected an unreadable date to be rejected")
+	}
+}
+
+func TestDataFileNormalizesYAMLMaps(t *testing.T) {
+	value, err := DataFile("nav.yaml", []byte("main:\n  home: /\n"))
+	if err != nil {
+		t.Fatalf("DataFile: %v", err)
+	}
+	nav, ok := value.(map[string]interface{})["main"].(map[string]interface{})
+	if !ok || nav["home"] != "/" {
+		t.Fatalf("expected nested string-keyed maps, got %#v", value)
+	}
+
+	if _, err := DataFile("nav.ini", nil); err == nil {
+		t.Fatal("expected an unsupported extension to be rejected")
+	}
+}
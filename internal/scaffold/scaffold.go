@@ -0,0 +1,97 @@
+package scaffold
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//go:embed themes tailwind
+var files embed.FS
+
+func Themes() []string {
+	entries, err := fs.ReadDir(files, "themes")
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Init writes the starter theme into dir, adding the Tailwind stylesheet and
+// head component on top when requested. Existing files are never overwritten.
+func Init(dir, theme string, tailwind bool) ([]string, error) {
+	root := path.Join("themes", theme)
+	if _, err := fs.Stat(files, root); err != nil {
+		return nil, fmt.Errorf("unknown theme %q (available: %s)", theme, strings.Join(Themes(), ", "))
+	}
+
+	sources := make(map[string]string)
+	if err := collect(root, sources); err != nil {
+		return nil, err
+	}
+	if tailwind {
+		if err := collect("tailwind", sources); err != nil {
+			return nil, err
+		}
+	}
+
+	targets := make([]string, 0, len(sources))
+	for rel := range sources {
+		targets = append(targets, rel)
+	}
+	sort.Strings(targets)
+
+	var conflicts []string
+	for _, rel := range targets {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(rel))); err == nil {
+			conflicts = append(conflicts, rel)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("refusing to overwrite existing files in %s: %s", dir, strings.Join(conflicts, ", "))
+	}
+
+	created := make([]string, 0, len(targets))
+	for _, rel := range targets {
+		content, err := files.ReadFile(sources[rel])
+		if err != nil {
+			return created, err
+		}
+
+		dst := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return created, err
+		}
+		if err := os.WriteFile(dst, content, 0644); err != nil {
+			return created, err
+		}
+		created = append(created, dst)
+	}
+
+	return created, nil
+}
+
+// collect maps each file under root to its path relative to root, letting
+// later calls override earlier ones.
+func collect(root string, sources map[string]string) error {
+	return fs.WalkDir(files, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		sources[strings.TrimPrefix(p, root+"/")] = p
+		return nil
+	})
+}
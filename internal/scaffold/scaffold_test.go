@@ -0,0 +1,53 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInitWritesThemeAndTailwindOverlay(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Init(dir, "minimal", true); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	for _, rel := range []string{"oojsite.yaml", "posts/hello-world.md", "site/index.html", "templates/post.html", "components/head.html", "static/main.css", "static/styles.css"} {
+		if _, err := os.Stat(filepath.Join(dir, rel)); err != nil {
+			t.Fatalf("expected %s to be created: %v", rel, err)
+		}
+	}
+
+	head, err := os.ReadFile(filepath.Join(dir, "components", "head.html"))
+	if err != nil {
+		t.Fatalf("read head.html: %v", err)
+	}
+	if !strings.Contains(string(head), "/static/styles.css") {
+		t.Fatalf("expected tailwind head component, got: %s", head)
+	}
+}
+
+func TestInitRefusesToOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "site"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "site", "index.html"), []byte("mine"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if _, err := Init(dir, "blog", false); err == nil {
+		t.Fatal("expected Init to refuse overwriting existing files")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "oojsite.yaml")); !os.IsNotExist(err) {
+		t.Fatal("expected nothing to be written when a conflict is found")
+	}
+}
+
+func TestInitRejectsUnknownTheme(t *testing.T) {
+	if _, err := Init(t.TempDir(), "nope", false); err == nil {
+		t.Fatal("expected unknown theme to be rejected")
+	}
+}
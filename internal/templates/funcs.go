@@ -26,7 +26,7 @@ func Funcs() template.FuncMap {
 		"reverse":               reversePosts,
 		"unique":                unique,
 		"get":                   getSafe,
-		"slugify":               slugify,
+		"slugify":               Slugify,
 		"truncate":              truncate,
 		"formatDate":            formatDate,
 	}
@@ -178,7 +178,7 @@ func getSafe(data map[string]interface{}, key string) string {
 	return ""
 }
 
-func slugify(s string) string {
+func Slugify(s string) string {
 	slug := strings.ToLower(s)
 	slug = strings.ReplaceAll(slug, " ", "-")
 	reg := regexp.MustCompile("[^a-z0-9-]+")
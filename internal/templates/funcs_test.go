@@ -74,7 +74,7 @@ func TestCollectionAndStringHelpers(t *testing.T) {
 		t.Fatalf("expected 3 unique tags, got %d", len(got))
 	}
 
-	if got := slugify("My Blog Post!"); got != "my-blog-post" {
+	if got := Slugify("My Blog Post!"); got != "my-blog-post" {
 		t.Fatalf("unexpected slugify result: %q", got)
 	}
 
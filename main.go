@@ -2,12 +2,13 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"oojsite/internal/app"
 )
 
 func main() {
-	if err := app.Run(); err != nil {
+	if err := app.Run(os.Args[1:]); err != nil {
 		log.Fatalf("%v", err)
 	}
 }